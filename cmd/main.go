@@ -3,18 +3,25 @@ package main
 import (
 	"context"
 	"log"
+	"os"
+	"os/signal"
 	"sync"
+	"syscall"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"api--sigacore-gateway/internal/auth"
+	"api--sigacore-gateway/internal/auth/janitor"
 	db "api--sigacore-gateway/internal/db/sqlc"
 	"api--sigacore-gateway/internal/gateway"
 	"api--sigacore-gateway/internal/util"
 )
 
 func main() {
-	ctx := context.Background()
+	// Tarefas em segundo plano param com SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	config, err := util.LoadConfig()
 	if err != nil {
 		log.Fatal("cannot load config:", err)
@@ -38,7 +45,7 @@ func main() {
 		log.Fatal("cannot create auth server:", err)
 	}
 
-	gatewayServer, err := gateway.NewGatewayServer(config)
+	gatewayServer, err := gateway.NewGatewayServer(ctx, config, store)
 	if err != nil {
 		log.Fatal("cannot create gateway server:", err)
 	}
@@ -69,6 +76,26 @@ func main() {
 	log.Printf("📍 Auth Service: http://%s", config.AuthServerAddress)
 	log.Printf("📍 Gateway: http://%s", config.GatewayServerAddress)
 
-	// Aguardar ambos os serviços
-	wg.Wait()
+	// Limpeza periódica das sessões expiradas
+	var workers sync.WaitGroup
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		janitor.New(store, config).Run(ctx)
+	}()
+
+	// Aguardar o sinal de parada ou o fim dos dois serviços
+	servicesDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(servicesDone)
+	}()
+
+	select {
+	case <-ctx.Done():
+		log.Println("Shutting down")
+	case <-servicesDone:
+		stop()
+	}
+	workers.Wait()
 }
@@ -4,9 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -20,8 +22,6 @@ import (
 	"api--sigacore-gateway/internal/util"
 )
 
-var Err_UserNotFound = errors.New("usuário não encontrado")
-
 type AuthHandler struct {
 	s           db.Store
 	authService services.AuthService
@@ -74,6 +74,8 @@ func (h *AuthHandler) CreateUser(c *gin.Context) {
 type loginRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	// Scope são os escopos OAuth2 pedidos, separados por espaço
+	Scope string `json:"scope"`
 }
 
 type loginResponse struct {
@@ -82,6 +84,7 @@ type loginResponse struct {
 	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
 	RefreshToken          string    `json:"refresh_token"`
 	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+	Scope                 string    `json:"scope,omitempty"`
 	User                  string    `json:"user"`
 }
 
@@ -92,32 +95,59 @@ func (h *AuthHandler) LoginUser(c *gin.Context) {
 		return
 	}
 
-	user, err := h.s.GetUser(c, req.Username)
+	// Usuário inexistente, fora da whitelist e senha errada recebem a mesma
+	// resposta
+	user, err := h.authService.Authenticate(c, req.Username, req.Password, c.ClientIP())
 	if err != nil {
-		log.Printf("loginUser: %v", err)
-		if user.FullName == "" {
-			errResponse(c, http.StatusNotFound, Err_UserNotFound)
+		loginErrorResponse(c, err)
+		return
+	}
+
+	scopes, err := h.authService.ResolveScopes(req.Scope)
+	if err != nil {
+		errResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Com segundo fator, a senha só rende um desafio; a sessão é criada em
+	// /users/login/mfa
+	mfa, err := h.authService.MFAEnabled(c, user.Username)
+	if err != nil {
+		errResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+	if mfa {
+		challenge, err := h.authService.CreateMFAChallenge(user.Username, scopes)
+		if err != nil {
+			errResponse(c, http.StatusInternalServerError, err)
 			return
 		}
-		errResponse(c, http.StatusInternalServerError, err)
+		c.JSON(http.StatusOK, challenge)
 		return
 	}
 
-	err = util.VerifyPassword(req.Password, user.HashedPassword)
+	h.startSession(c, user.Username, scopes)
+}
+
+// startSession cria a sessão de um login concluído e responde com os tokens
+func (h *AuthHandler) startSession(c *gin.Context, username string, scopes []string) {
+	// O ID do refresh token é o ID da sessão, que vai no access token. Os
+	// escopos também vão no refresh token, para que a renovação os mantenha
+	refreshToken, refreshPayload, err := h.token.CreateToken(username, h.config.RefreshTokenDuration,
+		token2.WithScopes(scopes...))
 	if err != nil {
-		errResponse(c, http.StatusUnauthorized, err)
+		errResponse(c, http.StatusInternalServerError, err)
 		return
 	}
 
-	accessToken, payload, err := h.token.CreateToken(user.Username, h.config.AccessTokenDuration)
-	fmt.Println(payload)
+	roles, permissions, err := h.authService.GetUserRoles(c, username)
 	if err != nil {
 		errResponse(c, http.StatusInternalServerError, err)
 		return
 	}
 
-	refreshToken, refreshPayload, err := h.token.CreateToken(user.Username, h.config.AccessTokenDuration)
-	fmt.Println(payload)
+	accessToken, payload, err := h.token.CreateToken(username, h.config.AccessTokenDuration,
+		token2.WithSessionID(refreshPayload.ID), token2.WithRoles(roles, permissions), token2.WithScopes(scopes...))
 	if err != nil {
 		errResponse(c, http.StatusInternalServerError, err)
 		return
@@ -125,15 +155,20 @@ func (h *AuthHandler) LoginUser(c *gin.Context) {
 
 	sessionParams := db.CreateSessionParams{
 		ID:           refreshPayload.ID,
-		Username:     user.Username,
+		Username:     username,
 		RefreshToken: refreshToken,
 		UserAgent:    c.Request.UserAgent(),
 		ClientIp:     c.ClientIP(),
 		IsBlocked:    false,
 		ExpiresAt:    refreshPayload.ExpiredAt,
+		FamilyID:     refreshPayload.ID,
 	}
-	session, err := h.s.CreateSession(c.Request.Context(), sessionParams)
+	session, err := h.authService.StartSession(c.Request.Context(), sessionParams)
 	if err != nil {
+		if errors.Is(err, services.ErrSessionLimitReached) {
+			errResponse(c, http.StatusConflict, services.ErrSessionLimitReached)
+			return
+		}
 		errResponse(c, http.StatusInternalServerError, err)
 		return
 	}
@@ -144,19 +179,41 @@ func (h *AuthHandler) LoginUser(c *gin.Context) {
 		AccessTokenExpiresAt:  payload.ExpiredAt,
 		RefreshToken:          refreshToken,
 		RefreshTokenExpiresAt: refreshPayload.ExpiredAt,
-		User:                  user.Username,
+		Scope:                 strings.Join(scopes, " "),
+		User:                  username,
 	}
 
 	c.JSON(http.StatusOK, rsp)
 }
 
+// loginErrorResponse responde a uma falha de senha ou de segundo fator. Um
+// bloqueio por excesso de falhas vira 429 com Retry-After
+func loginErrorResponse(c *gin.Context, err error) {
+	var (
+		locked   *services.LoginLockedError
+		tokenErr *token2.TokenError
+	)
+	switch {
+	case errors.As(err, &locked):
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+		errResponse(c, http.StatusTooManyRequests, err)
+	case errors.Is(err, services.ErrInvalidCredentials), errors.Is(err, services.ErrInvalidMFACode),
+		errors.Is(err, services.ErrMFANotEnabled), errors.Is(err, services.ErrMFADisabled),
+		errors.As(err, &tokenErr):
+		errResponse(c, http.StatusUnauthorized, err)
+	default:
+		log.Printf("login: %v", err)
+		errResponse(c, http.StatusInternalServerError, err)
+	}
+}
+
 // Handler para obter usuário (rota protegida)
 func (h *AuthHandler) GetUser(c *gin.Context) {
 	username := c.Param("username")
 
-	// Verificar autorização
+	// Verificar autorização: o próprio usuário ou quem pode ler qualquer um
 	authPayload := c.MustGet("authorization_payload").(*token2.Payload)
-	if username != authPayload.Username {
+	if username != authPayload.Username && !authPayload.HasPermission(services.PermissionUsersRead) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "account doesn't belong to the authenticated user"})
 		return
 	}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	token2 "api--sigacore-gateway/internal/token"
+)
+
+// JWKS publica as chaves públicas de verificação dos tokens. Só é registrado
+// quando o maker usa chaves assimétricas.
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	provider, ok := h.token.(token2.JWKSProvider)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no public keys"})
+		return
+	}
+
+	// Verificadores podem guardar as chaves por alguns minutos; chaves novas
+	// aparecem aqui antes de ativadas
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, provider.JWKS())
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"api--sigacore-gateway/internal/auth/models"
+)
+
+var errUnlockTarget = errors.New("exactly one of username or ip is required")
+
+// Handler administrativo para liberar um usuário ou um IP bloqueado por
+// falhas de login antes do fim do bloqueio
+func (h *AuthHandler) UnlockLogin(c *gin.Context) {
+	var req models.UnlockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if (req.Username == "") == (req.IP == "") {
+		errResponse(c, http.StatusBadRequest, errUnlockTarget)
+		return
+	}
+
+	unlocked, err := h.authService.UnlockLogin(c, req.Username, req.IP)
+	if err != nil {
+		errResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UnlockResponse{Unlocked: unlocked})
+}
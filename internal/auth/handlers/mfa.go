@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"api--sigacore-gateway/internal/auth/models"
+	"api--sigacore-gateway/internal/auth/services"
+	token2 "api--sigacore-gateway/internal/token"
+)
+
+// Handler do segundo passo do login: troca o token de desafio e um código
+// TOTP (ou de recuperação) pela sessão
+func (h *AuthHandler) LoginMFA(c *gin.Context) {
+	var req models.LoginMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	challenge, err := h.authService.VerifyMFAChallenge(c, req.MFAToken, req.Code, c.ClientIP())
+	if err != nil {
+		loginErrorResponse(c, err)
+		return
+	}
+
+	h.startSession(c, challenge.Username, challenge.Scopes)
+}
+
+// Handler para iniciar o cadastro TOTP do usuário autenticado. Retorna o
+// segredo e o URI otpauth do QR code; o cadastro só vale depois de
+// confirmado com um código
+func (h *AuthHandler) EnrollTOTP(c *gin.Context) {
+	authPayload := c.MustGet("authorization_payload").(*token2.Payload)
+
+	rsp, err := h.authService.EnrollTOTP(c, authPayload.Username)
+	if err != nil {
+		errResponse(c, mfaErrorStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rsp)
+}
+
+// Handler para confirmar o cadastro TOTP com o primeiro código do app.
+// Retorna os códigos de recuperação, exibidos só desta vez
+func (h *AuthHandler) ConfirmTOTP(c *gin.Context) {
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	authPayload := c.MustGet("authorization_payload").(*token2.Payload)
+	rsp, err := h.authService.ConfirmTOTP(c, authPayload.Username, req.Code)
+	if err != nil {
+		errResponse(c, mfaErrorStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rsp)
+}
+
+// Handler para desligar o segundo fator; exige um código TOTP ou de
+// recuperação
+func (h *AuthHandler) DisableTOTP(c *gin.Context) {
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	authPayload := c.MustGet("authorization_payload").(*token2.Payload)
+	err := h.authService.DisableTOTP(c, authPayload.Username, req.Code, c.ClientIP())
+	if err != nil {
+		var locked *services.LoginLockedError
+		if errors.As(err, &locked) {
+			loginErrorResponse(c, err)
+			return
+		}
+		errResponse(c, mfaErrorStatus(err), err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func mfaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidMFACode):
+		return http.StatusUnauthorized
+	case errors.Is(err, services.ErrMFAAlreadyEnabled):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrMFANotPending), errors.Is(err, services.ErrMFANotEnabled),
+		errors.Is(err, services.ErrMFADisabled):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+
+	"api--sigacore-gateway/internal/auth/services"
+	db "api--sigacore-gateway/internal/db/sqlc"
+	"api--sigacore-gateway/internal/shared/middleware"
+	"api--sigacore-gateway/internal/shared/revocation"
+	token2 "api--sigacore-gateway/internal/token"
+	"api--sigacore-gateway/internal/util"
+)
+
+const testPassword = "correct horse battery"
+
+// fakeLoginStore guarda em memória o que o login com segundo fator usa. Os
+// demais métodos do Store ficam nil e entram em pânico se chamados.
+type fakeLoginStore struct {
+	db.Store
+
+	mu    sync.Mutex
+	users map[string]db.User
+	totp  map[string]db.UserTotp
+}
+
+func (f *fakeLoginStore) GetUser(_ context.Context, username string) (db.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	user, ok := f.users[username]
+	if !ok {
+		return db.User{}, pgx.ErrNoRows
+	}
+	return user, nil
+}
+
+func (f *fakeLoginStore) GetTOTP(_ context.Context, username string) (db.UserTotp, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	totp, ok := f.totp[username]
+	if !ok {
+		return db.UserTotp{}, pgx.ErrNoRows
+	}
+	return totp, nil
+}
+
+func (f *fakeLoginStore) UseTOTPStep(_ context.Context, arg db.UseTOTPStepParams) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	totp := f.totp[arg.Username]
+	if totp.LastUsedStep >= arg.Step {
+		return 0, nil
+	}
+	totp.LastUsedStep = arg.Step
+	f.totp[arg.Username] = totp
+	return 1, nil
+}
+
+func (f *fakeLoginStore) UseRecoveryCode(context.Context, db.UseRecoveryCodeParams) (int64, error) {
+	return 0, nil
+}
+
+func (f *fakeLoginStore) GetLoginLock(context.Context, []string) (db.GetLoginLockRow, error) {
+	return db.GetLoginLockRow{Now: time.Now()}, nil
+}
+
+func (f *fakeLoginStore) RecordLoginFailure(context.Context, db.RecordLoginFailureParams) (int32, error) {
+	return 1, nil
+}
+
+func (f *fakeLoginStore) ResetLoginFailures(context.Context, string) (int64, error) {
+	return 0, nil
+}
+
+func (f *fakeLoginStore) RevokeToken(context.Context, db.RevokeTokenParams) error {
+	return nil
+}
+
+func (f *fakeLoginStore) ListUserRoles(context.Context, string) ([]db.Role, error) {
+	return nil, nil
+}
+
+func (f *fakeLoginStore) CreateSessionTx(_ context.Context, arg db.CreateSessionTxParams) (db.CreateSessionTxResult, error) {
+	return db.CreateSessionTxResult{Session: db.Session{ID: arg.Session.ID, Username: arg.Session.Username}}, nil
+}
+
+// newMFATestRouter monta as rotas de login do serviço de auth sobre um
+// usuário "alice" com segundo fator ativo, e retorna o segredo TOTP dele.
+func newMFATestRouter(t *testing.T) (*gin.Engine, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	config := util.Config{
+		TokenSymmetricKey:       "test_token_key_0123456789abcdefg",
+		TokenIssuer:             "sigacore-auth-test",
+		TokenAudience:           "sigacore",
+		AccessTokenDuration:     time.Minute,
+		RefreshTokenDuration:    time.Hour,
+		LoginLockoutThreshold:   5,
+		LoginLockoutIPThreshold: 20,
+		LoginLockoutBase:        time.Second,
+		LoginLockoutMax:         time.Minute,
+		LoginFailureWindow:      time.Hour,
+		MFAIssuer:               "SigaCore",
+		MFAChallengeDuration:    time.Minute,
+		MFARecoveryCodes:        3,
+		MFASecretKey:            "test_mfa_key_0123456789abcdefghi",
+	}
+
+	hashedPassword, err := util.HashPassword(testPassword)
+	require.NoError(t, err)
+	secret, err := util.GenerateTOTPSecret()
+	require.NoError(t, err)
+	encrypted, err := util.EncryptSecret([]byte(config.MFASecretKey), secret, "alice")
+	require.NoError(t, err)
+
+	store := &fakeLoginStore{
+		users: map[string]db.User{"alice": {Username: "alice", HashedPassword: hashedPassword, IsWhitelisted: true}},
+		totp:  map[string]db.UserTotp{"alice": {Username: "alice", Secret: encrypted, IsEnabled: true}},
+	}
+
+	revocations := revocation.NewList(store, time.Hour)
+	tokenMaker, err := token2.NewMakerFromConfig(config)
+	require.NoError(t, err)
+	tokenMaker = token2.NewRevocationMaker(tokenMaker, revocations)
+	mfaMaker, err := token2.NewMFAMakerFromConfig(config)
+	require.NoError(t, err)
+	mfaMaker = token2.NewRevocationMaker(mfaMaker, revocations)
+
+	handler := &AuthHandler{
+		s:           store,
+		authService: services.NewAuthService(store, tokenMaker, mfaMaker, revocations, config),
+		token:       tokenMaker,
+		config:      config,
+	}
+
+	router := gin.New()
+	router.POST("/users/login", handler.LoginUser)
+	router.POST("/users/login/mfa", handler.LoginMFA)
+	router.GET("/users/:username", middleware.AuthMiddleware(tokenMaker), handler.GetUser)
+	return router, secret
+}
+
+func postJSON(t *testing.T, router *gin.Engine, path string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	data, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data)))
+	return rec
+}
+
+func getUser(router *gin.Engine, accessToken string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/users/alice", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestLoginWithMFA(t *testing.T) {
+	router, secret := newMFATestRouter(t)
+
+	rec := postJSON(t, router, "/users/login", gin.H{"username": "alice", "password": "wrong password"})
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Com 2FA ativo, a senha rende só o desafio: nenhum token, nenhuma sessão
+	rec = postJSON(t, router, "/users/login", gin.H{"username": "alice", "password": testPassword})
+	require.Equal(t, http.StatusOK, rec.Code)
+	var challenge map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &challenge))
+	require.ElementsMatch(t, []string{"mfa_required", "mfa_token", "mfa_token_expires_at"}, slices.Collect(maps.Keys(challenge)))
+	require.Equal(t, true, challenge["mfa_required"])
+	mfaToken := challenge["mfa_token"].(string)
+
+	// O desafio não serve como access token (audience diferente)
+	rec = getUser(router, mfaToken)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Contains(t, rec.Body.String(), token2.ErrTokenAudience.Error())
+
+	rec = postJSON(t, router, "/users/login/mfa", gin.H{"mfa_token": mfaToken, "code": "not-a-code"})
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	code, err := util.TOTPCode(secret, util.TOTPStep(time.Now()))
+	require.NoError(t, err)
+	rec = postJSON(t, router, "/users/login/mfa", gin.H{"mfa_token": mfaToken, "code": code})
+	require.Equal(t, http.StatusOK, rec.Code)
+	var session loginResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &session))
+	require.Equal(t, "alice", session.User)
+	require.NotEmpty(t, session.RefreshToken)
+	require.Equal(t, http.StatusOK, getUser(router, session.AccessToken).Code)
+
+	// Desafio já usado e código já usado são recusados
+	rec = postJSON(t, router, "/users/login/mfa", gin.H{"mfa_token": mfaToken, "code": code})
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = postJSON(t, router, "/users/login", gin.H{"username": "alice", "password": testPassword})
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &challenge))
+	rec = postJSON(t, router, "/users/login/mfa", gin.H{"mfa_token": challenge["mfa_token"], "code": code})
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Contains(t, rec.Body.String(), services.ErrInvalidMFACode.Error())
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"api--sigacore-gateway/internal/auth/models"
+	"api--sigacore-gateway/internal/auth/services"
+	token2 "api--sigacore-gateway/internal/token"
+)
+
+var errRevokeTarget = errors.New("exactly one of token, session_id or username is required")
+
+// Handler administrativo para revogar um token, uma sessão ou todas as
+// sessões de um usuário antes de expirarem. O gateway passa a recusar os
+// tokens assim que recebe a notificação do banco
+func (h *AuthHandler) Revoke(c *gin.Context) {
+	var req models.RevokeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	targets := 0
+	for _, set := range []bool{req.Token != "", req.SessionID != uuid.Nil, req.Username != ""} {
+		if set {
+			targets++
+		}
+	}
+	if targets != 1 {
+		errResponse(c, http.StatusBadRequest, errRevokeTarget)
+		return
+	}
+
+	var (
+		revoked []uuid.UUID
+		err     error
+	)
+	switch {
+	case req.Token != "":
+		revoked, err = h.authService.RevokeToken(c, req.Token, req.Reason)
+	case req.SessionID != uuid.Nil:
+		revoked, err = h.authService.RevokeSession(c, req.SessionID, req.Reason)
+	default:
+		revoked, err = h.authService.RevokeUser(c, req.Username, req.Reason)
+	}
+	if err != nil {
+		errResponse(c, revokeErrorStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RevokeResponse{Revoked: revoked})
+}
+
+func revokeErrorStatus(err error) int {
+	var tokenErr *token2.TokenError
+	switch {
+	case errors.As(err, &tokenErr):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrSessionNotFound), errors.Is(err, services.ErrUserNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
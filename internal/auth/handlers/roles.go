@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"api--sigacore-gateway/internal/auth/models"
+	"api--sigacore-gateway/internal/auth/services"
+)
+
+// Handler para listar os papéis de um usuário
+func (h *AuthHandler) GetUserRoles(c *gin.Context) {
+	h.respondUserRoles(c, c.Param("username"), http.StatusOK)
+}
+
+// Handler para atribuir um papel a um usuário. Vale a partir do próximo
+// login ou renovação do access token
+func (h *AuthHandler) AddUserRole(c *gin.Context) {
+	var req models.AddUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	username := c.Param("username")
+	if err := h.authService.AddUserRole(c, username, req.Role); err != nil {
+		errResponse(c, roleErrorStatus(err), err)
+		return
+	}
+	h.respondUserRoles(c, username, http.StatusCreated)
+}
+
+// Handler para remover um papel de um usuário
+func (h *AuthHandler) RemoveUserRole(c *gin.Context) {
+	username := c.Param("username")
+	if err := h.authService.RemoveUserRole(c, username, c.Param("role")); err != nil {
+		errResponse(c, roleErrorStatus(err), err)
+		return
+	}
+	h.respondUserRoles(c, username, http.StatusOK)
+}
+
+func (h *AuthHandler) respondUserRoles(c *gin.Context, username string, status int) {
+	roles, permissions, err := h.authService.GetUserRoles(c, username)
+	if err != nil {
+		errResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(status, models.UserRolesResponse{
+		Username:    username,
+		Roles:       roles,
+		Permissions: permissions,
+	})
+}
+
+func roleErrorStatus(err error) int {
+	if errors.Is(err, services.ErrRoleNotFound) || errors.Is(err, services.ErrUserNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
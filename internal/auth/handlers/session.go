@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"api--sigacore-gateway/internal/auth/models"
+	"api--sigacore-gateway/internal/auth/services"
+	token2 "api--sigacore-gateway/internal/token"
+)
+
+var (
+	errNoSession        = errors.New("access token is not bound to a session")
+	errSessionForbidden = errors.New("sessions don't belong to the authenticated user")
+)
+
+// Tamanho de página padrão da listagem de sessões
+const defaultSessionsPageSize = 10
+
+// Handler de logout: bloqueia a sessão do access token usado e revoga os
+// tokens dela
+func (h *AuthHandler) Logout(c *gin.Context) {
+	authPayload := c.MustGet("authorization_payload").(*token2.Payload)
+	if authPayload.SessionID == uuid.Nil {
+		errResponse(c, http.StatusBadRequest, errNoSession)
+		return
+	}
+
+	revoked, err := h.authService.Logout(c, authPayload.SessionID)
+	if err != nil {
+		errResponse(c, revokeErrorStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RevokeResponse{Revoked: revoked})
+}
+
+// Handler de logout em todos os dispositivos: bloqueia todas as sessões do
+// usuário
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	authPayload := c.MustGet("authorization_payload").(*token2.Payload)
+
+	revoked, err := h.authService.LogoutAll(c, authPayload.Username)
+	if err != nil {
+		errResponse(c, revokeErrorStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RevokeResponse{Revoked: revoked})
+}
+
+// Handler para listar as sessões ativas de um usuário (os dispositivos
+// conectados). O próprio usuário vê as suas; quem tem sessions:manage, as de
+// qualquer um
+func (h *AuthHandler) ListUserSessions(c *gin.Context) {
+	var req models.ListSessionsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		errResponse(c, http.StatusBadRequest, err)
+		return
+	}
+	if req.PageID == 0 {
+		req.PageID = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = defaultSessionsPageSize
+	}
+
+	username := c.Param("username")
+	authPayload, ok := authorizeSessions(c, username)
+	if !ok {
+		return
+	}
+
+	sessions, total, err := h.authService.ListActiveSessions(c, username, req.PageID, req.PageSize)
+	if err != nil {
+		errResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	rsp := models.ListSessionsResponse{
+		Sessions: make([]models.SessionResponse, 0, len(sessions)),
+		PageID:   req.PageID,
+		PageSize: req.PageSize,
+		Total:    total,
+	}
+	for _, session := range sessions {
+		rsp.Sessions = append(rsp.Sessions, models.NewSessionResponse(session, authPayload.SessionID))
+	}
+	c.JSON(http.StatusOK, rsp)
+}
+
+// Handler para encerrar uma sessão específica de um usuário
+func (h *AuthHandler) RevokeUserSession(c *gin.Context) {
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	username := c.Param("username")
+	authPayload, ok := authorizeSessions(c, username)
+	if !ok {
+		return
+	}
+
+	revoked, err := h.authService.RevokeUserSession(c, username, sessionID, "revoked by "+authPayload.Username)
+	if err != nil {
+		errResponse(c, revokeErrorStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RevokeResponse{Revoked: revoked})
+}
+
+// authorizeSessions permite o próprio usuário ou quem tem sessions:manage.
+// Responde 403 e retorna false nos demais casos
+func authorizeSessions(c *gin.Context, username string) (*token2.Payload, bool) {
+	authPayload := c.MustGet("authorization_payload").(*token2.Payload)
+	if username != authPayload.Username && !authPayload.HasPermission(services.PermissionSessionsManage) {
+		errResponse(c, http.StatusForbidden, errSessionForbidden)
+		return nil, false
+	}
+	return authPayload, true
+}
@@ -28,7 +28,7 @@ func (h *AuthHandler) RenewAccessToken(c *gin.Context) {
 		switch err.Error() {
 		case "session not found":
 			statusCode = http.StatusNotFound
-		case "session blocked", "incorrect session", "session expired":
+		case "session blocked", "incorrect session", "session expired", "refresh token reused":
 			statusCode = http.StatusUnauthorized
 		}
 		c.JSON(statusCode, gin.H{"error": err.Error()})
@@ -0,0 +1,102 @@
+// Package janitor apaga periodicamente as sessões expiradas. Cada login (e
+// cada renovação, com a rotação do refresh token) grava uma sessão, e sem
+// limpeza a tabela só cresce. Na mesma rodada apaga os contadores de falhas
+// de login que já saíram da janela.
+package janitor
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	db "api--sigacore-gateway/internal/db/sqlc"
+	"api--sigacore-gateway/internal/util"
+)
+
+// lockKey é o advisory lock que garante uma única instância limpando por vez
+const lockKey int64 = 0x5349474153455353 // "SIGASESS"
+
+// Janitor apaga, a cada interval, as sessões expiradas há mais de
+// gracePeriod, em lotes de batchSize. Com várias réplicas, só a que obtém o
+// advisory lock limpa; as demais pulam a rodada.
+type Janitor struct {
+	store       db.Store
+	interval    time.Duration
+	gracePeriod time.Duration
+	batchSize   int32
+
+	loginFailureWindow time.Duration
+}
+
+func New(store db.Store, cfg util.Config) *Janitor {
+	return &Janitor{
+		store:       store,
+		interval:    cfg.SessionCleanupInterval,
+		gracePeriod: cfg.SessionCleanupGracePeriod,
+		batchSize:   int32(cfg.SessionCleanupBatchSize),
+
+		loginFailureWindow: cfg.LoginFailureWindow,
+	}
+}
+
+// Run limpa na hora e depois a cada interval. Bloqueia até ctx ser
+// cancelado; um lote em andamento é concluído ou desfeito pela transação.
+func (j *Janitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := j.Sweep(ctx)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			log.Printf("session janitor: %v (deleted %d sessions before failing)", err, deleted)
+		case deleted > 0:
+			log.Printf("session janitor: deleted %d expired sessions", deleted)
+		}
+
+		stale, err := j.store.DeleteStaleLoginAttempts(ctx, j.loginFailureWindow.Microseconds())
+		switch {
+		case err != nil && ctx.Err() == nil:
+			log.Printf("session janitor: cannot delete stale login attempts: %v", err)
+		case stale > 0:
+			log.Printf("session janitor: deleted %d stale login attempts", stale)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep apaga lotes até não sobrar sessão expirada e retorna quantas apagou.
+// Cada lote é uma transação curta com o advisory lock; se outra instância o
+// detém, Sweep para sem erro.
+func (j *Janitor) Sweep(ctx context.Context) (int64, error) {
+	var total int64
+	for ctx.Err() == nil {
+		var deleted int64
+		err := j.store.WithAdvisoryLock(ctx, lockKey, func(q *db.Queries) error {
+			var err error
+			deleted, err = q.DeleteExpiredSessions(ctx, db.DeleteExpiredSessionsParams{
+				GracePeriodUs: j.gracePeriod.Microseconds(),
+				BatchSize:     j.batchSize,
+			})
+			return err
+		})
+		if errors.Is(err, db.ErrLockNotAcquired) {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+
+		total += deleted
+		if deleted < int64(j.batchSize) {
+			return total, nil
+		}
+	}
+	return total, ctx.Err()
+}
@@ -1,5 +1,7 @@
 package models
 
+import "github.com/google/uuid"
+
 type CreateUserRequest struct {
 	Username      string `json:"username" binding:"required,username"`
 	Password      string `json:"password" binding:"required,min=6"`
@@ -11,8 +13,47 @@ type CreateUserRequest struct {
 type LoginUserRequest struct {
 	Username string `json:"username" binding:"required,username"`
 	Password string `json:"password" binding:"required,min=6"`
+	// Scope são os escopos OAuth2 pedidos, separados por espaço
+	Scope string `json:"scope,omitempty"`
+}
+
+// LoginMFARequest conclui um login com segundo fator: o token de desafio
+// recebido em /users/login e um código TOTP ou de recuperação
+type LoginMFARequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required,max=32"`
+}
+
+// MFACodeRequest traz um código TOTP (ou, onde aceito, de recuperação)
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required,max=32"`
 }
 
 type RenewAccessTokenRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
+
+type AddUserRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// RevokeRequest identifica o que revogar: exatamente um entre token,
+// session_id e username
+type RevokeRequest struct {
+	Token     string    `json:"token,omitempty"`
+	SessionID uuid.UUID `json:"session_id,omitempty"`
+	Username  string    `json:"username,omitempty"`
+	Reason    string    `json:"reason,omitempty" binding:"max=255"`
+}
+
+// UnlockRequest identifica o que desbloquear: exatamente um entre username e
+// ip
+type UnlockRequest struct {
+	Username string `json:"username,omitempty"`
+	IP       string `json:"ip,omitempty" binding:"omitempty,ip"`
+}
+
+type ListSessionsRequest struct {
+	PageID   int32 `form:"page_id" binding:"omitempty,min=1"`
+	PageSize int32 `form:"page_size" binding:"omitempty,min=1,max=50"`
+}
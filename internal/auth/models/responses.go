@@ -2,6 +2,7 @@ package models
 
 import (
 	db "api--sigacore-gateway/internal/db/sqlc"
+	"api--sigacore-gateway/internal/util"
 	"time"
 
 	"github.com/google/uuid"
@@ -23,12 +24,94 @@ type LoginUserResponse struct {
 	AccessTokenExpiresAt  time.Time    `json:"access_token_expires_at"`
 	RefreshToken          string       `json:"refresh_token"`
 	RefreshTokenExpiresAt time.Time    `json:"refresh_token_expires_at"`
+	Scope                 string       `json:"scope,omitempty"`
 	User                  UserResponse `json:"user"`
+	// MFA vem preenchido quando o usuário tem segundo fator: os tokens ficam
+	// vazios e o login continua em /users/login/mfa
+	MFA *MFAChallengeResponse `json:"mfa,omitempty"`
 }
 
+// MFAChallengeResponse é a resposta do login de um usuário com segundo
+// fator. MFAToken vale só para /users/login/mfa e expira em poucos minutos
+type MFAChallengeResponse struct {
+	MFARequired       bool      `json:"mfa_required"`
+	MFAToken          string    `json:"mfa_token"`
+	MFATokenExpiresAt time.Time `json:"mfa_token_expires_at"`
+}
+
+// EnrollTOTPResponse traz o segredo de um cadastro TOTP ainda pendente.
+// ProvisioningURI (otpauth://) é o conteúdo do QR code lido pelo app
+// autenticador; Secret serve para digitar a chave à mão
+type EnrollTOTPResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// RecoveryCodesResponse traz os códigos de recuperação em texto puro. Eles
+// só são exibidos aqui: o banco guarda apenas os hashes
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// RenewAccessTokenResponse traz o novo refresh token: o anterior não vale mais
 type RenewAccessTokenResponse struct {
-	AccessToken          string    `json:"access_token"`
-	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+	SessionID             uuid.UUID `json:"session_id"`
+	AccessToken           string    `json:"access_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+}
+
+type UserRolesResponse struct {
+	Username    string   `json:"username"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+// RevokeResponse lista os IDs (de tokens ou sessões) colocados na lista de
+// revogação
+type RevokeResponse struct {
+	Revoked []uuid.UUID `json:"revoked"`
+}
+
+// UnlockResponse indica se havia falhas ou bloqueio a remover
+type UnlockResponse struct {
+	Unlocked bool `json:"unlocked"`
+}
+
+// SessionResponse é uma sessão ativa (um dispositivo conectado). SignedInAt é
+// o login; LastRenewedAt, a última renovação do refresh token
+type SessionResponse struct {
+	ID            uuid.UUID      `json:"id"`
+	Current       bool           `json:"current"`
+	Device        util.UserAgent `json:"device"`
+	UserAgent     string         `json:"user_agent"`
+	ClientIP      string         `json:"client_ip"`
+	SignedInAt    time.Time      `json:"signed_in_at"`
+	LastRenewedAt time.Time      `json:"last_renewed_at"`
+	ExpiresAt     time.Time      `json:"expires_at"`
+}
+
+type ListSessionsResponse struct {
+	Sessions []SessionResponse `json:"sessions"`
+	PageID   int32             `json:"page_id"`
+	PageSize int32             `json:"page_size"`
+	Total    int64             `json:"total"`
+}
+
+// NewSessionResponse converte uma sessão ativa; current marca a sessão do
+// token usado na requisição
+func NewSessionResponse(session db.ListActiveSessionsRow, current uuid.UUID) SessionResponse {
+	return SessionResponse{
+		ID:            session.ID,
+		Current:       session.ID == current,
+		Device:        util.ParseUserAgent(session.UserAgent),
+		UserAgent:     session.UserAgent,
+		ClientIP:      session.ClientIp,
+		SignedInAt:    session.SignedInAt,
+		LastRenewedAt: session.CreatedAt,
+		ExpiresAt:     session.ExpiresAt,
+	}
 }
 
 // Helper function para converter db.User em UserResponse
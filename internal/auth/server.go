@@ -13,6 +13,7 @@ import (
 	"api--sigacore-gateway/internal/auth/services"
 	db "api--sigacore-gateway/internal/db/sqlc"
 	"api--sigacore-gateway/internal/shared/middleware"
+	"api--sigacore-gateway/internal/shared/revocation"
 	token2 "api--sigacore-gateway/internal/token"
 	"api--sigacore-gateway/internal/util"
 )
@@ -35,13 +36,25 @@ func NewAuthServer(cfg util.Config, store db.Store) (*AuthServer, error) {
 		}
 	}
 
-	tokenMaker, err := token2.NewPasetoMaker(cfg.TokenSymmetricKey)
+	tokenMaker, err := token2.NewMakerFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	revocations := revocation.NewList(store, cfg.TokenRevocationRefresh)
+	revocations.Start(ctx)
+	tokenMaker = token2.NewRevocationMaker(tokenMaker, revocations)
+	// Os desafios MFA também passam pela lista: são revogados ao ser usados
+	mfaMaker, err := token2.NewMFAMakerFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	mfaMaker = token2.NewRevocationMaker(mfaMaker, revocations)
 	conn, err := pgxpool.New(ctx, cfg.ConnStr)
 	if err != nil {
 		return nil, err
 	}
 
-	authService := services.NewAuthService(store, tokenMaker, cfg)
+	authService := services.NewAuthService(store, tokenMaker, mfaMaker, revocations, cfg)
 	authHandler := handlers.NewAuthHandler(authService, tokenMaker, conn, cfg)
 
 	server := &AuthServer{
@@ -60,11 +73,42 @@ func (s *AuthServer) setupRoutes() {
 	// Rotas públicas
 	router.POST("/users", s.authHandler.CreateUser)
 	router.POST("/users/login", s.authHandler.LoginUser)
+	router.POST("/users/login/mfa", s.authHandler.LoginMFA)
 	router.POST("/token/renew", s.authHandler.RenewAccessToken)
+	if provider, ok := s.tokenMaker.(token2.JWKSProvider); ok && len(provider.JWKS().Keys) > 0 {
+		router.GET("/.well-known/jwks.json", s.authHandler.JWKS)
+	}
 
 	// Rotas protegidas
 	authRoutes := router.Group("/").Use(middleware.AuthMiddleware(s.tokenMaker))
 	authRoutes.GET("/users/:username", s.authHandler.GetUser)
+	authRoutes.POST("/users/logout", s.authHandler.Logout)
+	authRoutes.POST("/users/logout-all", s.authHandler.LogoutAll)
+
+	// Segundo fator (TOTP) do próprio usuário
+	authRoutes.POST("/users/mfa/totp", s.authHandler.EnrollTOTP)
+	authRoutes.POST("/users/mfa/totp/verify", s.authHandler.ConfirmTOTP)
+	authRoutes.DELETE("/users/mfa/totp", s.authHandler.DisableTOTP)
+
+	// Gestão de papéis
+	rolesRoutes := router.Group("/users/:username/roles").
+		Use(middleware.AuthMiddleware(s.tokenMaker), middleware.RequirePermission(services.PermissionRolesManage))
+	rolesRoutes.GET("", s.authHandler.GetUserRoles)
+	rolesRoutes.POST("", s.authHandler.AddUserRole)
+	rolesRoutes.DELETE("/:role", s.authHandler.RemoveUserRole)
+
+	// Sessões (dispositivos conectados) do usuário
+	sessionsRoutes := router.Group("/users/:username/sessions").Use(middleware.AuthMiddleware(s.tokenMaker))
+	sessionsRoutes.GET("", s.authHandler.ListUserSessions)
+	sessionsRoutes.DELETE("/:id", s.authHandler.RevokeUserSession)
+
+	// Revogação de tokens
+	router.POST("/admin/revocations", middleware.AuthMiddleware(s.tokenMaker),
+		middleware.RequirePermission(services.PermissionTokensRevoke), s.authHandler.Revoke)
+
+	// Desbloqueio de logins bloqueados por falhas
+	router.POST("/admin/unlock", middleware.AuthMiddleware(s.tokenMaker),
+		middleware.RequirePermission(services.PermissionLoginUnlock), s.authHandler.UnlockLogin)
 
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
@@ -2,36 +2,96 @@ package services
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
+	"log"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 
 	"api--sigacore-gateway/internal/auth/models"
 	db "api--sigacore-gateway/internal/db/sqlc"
+	"api--sigacore-gateway/internal/shared/revocation"
 	token2 "api--sigacore-gateway/internal/token"
 	"api--sigacore-gateway/internal/util"
 )
 
+// Permissões verificadas pelas rotas do serviço de auth
+const (
+	// PermissionUsersRead permite consultar qualquer usuário, não só o próprio
+	PermissionUsersRead = "users:read"
+	// PermissionRolesManage permite atribuir e remover papéis de usuários
+	PermissionRolesManage = "roles:manage"
+	// PermissionTokensRevoke permite revogar tokens, sessões e usuários
+	PermissionTokensRevoke = "tokens:revoke"
+	// PermissionLoginUnlock permite desbloquear usuários e IPs bloqueados por
+	// falhas de login
+	PermissionLoginUnlock = "users:unlock"
+	// PermissionSessionsManage permite ver e encerrar sessões de qualquer
+	// usuário, não só as próprias
+	PermissionSessionsManage = "sessions:manage"
+)
+
+var (
+	ErrRoleNotFound    = errors.New("role not found")
+	ErrUserNotFound    = errors.New("user not found")
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrRefreshTokenReused indica um refresh token já rotacionado
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+	ErrInvalidScope       = errors.New("invalid scope")
+	// ErrSessionLimitReached indica que o usuário atingiu o limite de sessões
+	// ativas com SESSION_LIMIT_POLICY=reject
+	ErrSessionLimitReached = db.ErrSessionLimitReached
+)
+
 type AuthService interface {
 	CreateUser(ctx context.Context, req models.CreateUserRequest) (db.User, error)
 	LoginUser(ctx *gin.Context, req models.LoginUserRequest) (models.LoginUserResponse, error)
 	GetUser(ctx context.Context, username string) (db.User, error)
 	RenewAccessToken(ctx context.Context, payload *token2.Payload, refreshToken string) (models.RenewAccessTokenResponse, error)
+	GetUserRoles(ctx context.Context, username string) (roles, permissions []string, err error)
+	AddUserRole(ctx context.Context, username, role string) error
+	RemoveUserRole(ctx context.Context, username, role string) error
+	ResolveScopes(requested string) ([]string, error)
+	StartSession(ctx context.Context, arg db.CreateSessionParams) (db.Session, error)
+	Authenticate(ctx context.Context, username, password, clientIP string) (db.User, error)
+	UnlockLogin(ctx context.Context, username, clientIP string) (bool, error)
+	EnrollTOTP(ctx context.Context, username string) (models.EnrollTOTPResponse, error)
+	ConfirmTOTP(ctx context.Context, username, code string) (models.RecoveryCodesResponse, error)
+	DisableTOTP(ctx context.Context, username, code, clientIP string) error
+	MFAEnabled(ctx context.Context, username string) (bool, error)
+	CreateMFAChallenge(username string, scopes []string) (models.MFAChallengeResponse, error)
+	VerifyMFAChallenge(ctx context.Context, challenge, code, clientIP string) (*token2.Payload, error)
+	RevokeToken(ctx context.Context, rawToken, reason string) ([]uuid.UUID, error)
+	RevokeSession(ctx context.Context, sessionID uuid.UUID, reason string) ([]uuid.UUID, error)
+	RevokeUser(ctx context.Context, username, reason string) ([]uuid.UUID, error)
+	Logout(ctx context.Context, sessionID uuid.UUID) ([]uuid.UUID, error)
+	LogoutAll(ctx context.Context, username string) ([]uuid.UUID, error)
+	ListActiveSessions(ctx context.Context, username string, pageID, pageSize int32) ([]db.ListActiveSessionsRow, int64, error)
+	RevokeUserSession(ctx context.Context, username string, sessionID uuid.UUID, reason string) ([]uuid.UUID, error)
 }
 
 type authService struct {
 	store      db.Store
 	tokenMaker token2.Maker
-	config     util.Config
+	// mfaMaker emite os tokens de desafio do login com segundo fator, com
+	// audience própria (token.NewMFAMakerFromConfig)
+	mfaMaker    token2.Maker
+	revocations *revocation.List
+	config      util.Config
 }
 
-func NewAuthService(store db.Store, tokenMaker token2.Maker, config util.Config) AuthService {
+func NewAuthService(store db.Store, tokenMaker, mfaMaker token2.Maker, revocations *revocation.List, config util.Config) AuthService {
 	return &authService{
-		store:      store,
-		tokenMaker: tokenMaker,
-		config:     config,
+		store:       store,
+		tokenMaker:  tokenMaker,
+		mfaMaker:    mfaMaker,
+		revocations: revocations,
+		config:      config,
 	}
 }
 
@@ -52,39 +112,62 @@ func (s *authService) CreateUser(ctx context.Context, req models.CreateUserReque
 	return s.store.CreateUser(ctx, arg)
 }
 
+// LoginUser faz o login completo: confere a senha (Authenticate), emite o
+// desafio de segundo fator ou os tokens e grava a sessão por StartSession,
+// que aplica o limite de sessões ativas do usuário.
 func (s *authService) LoginUser(ctx *gin.Context, req models.LoginUserRequest) (models.LoginUserResponse, error) {
-	user, err := s.store.GetUser(ctx, req.Username)
+	user, err := s.Authenticate(ctx, req.Username, req.Password, ctx.ClientIP())
 	if err != nil {
 		return models.LoginUserResponse{}, err
 	}
 
-	// Verificar se o usuário está na whitelist
-	if !user.IsWhitelisted {
-		return models.LoginUserResponse{}, fmt.Errorf("user not whitelisted")
+	scopes, err := s.ResolveScopes(req.Scope)
+	if err != nil {
+		return models.LoginUserResponse{}, err
 	}
 
-	err = util.VerifyPassword(req.Password, user.HashedPassword)
+	// Com segundo fator, a senha só rende um desafio; a sessão é criada em
+	// /users/login/mfa
+	mfa, err := s.MFAEnabled(ctx, user.Username)
 	if err != nil {
-		return models.LoginUserResponse{}, fmt.Errorf("invalid credentials")
+		return models.LoginUserResponse{}, err
+	}
+	if mfa {
+		challenge, err := s.CreateMFAChallenge(user.Username, scopes)
+		if err != nil {
+			return models.LoginUserResponse{}, err
+		}
+		return models.LoginUserResponse{User: models.NewUserResponse(user), MFA: &challenge}, nil
 	}
 
-	accessToken, accessPayload, err := s.tokenMaker.CreateToken(
+	// O refresh token guarda os escopos para que a renovação os mantenha
+	refreshToken, refreshPayload, err := s.tokenMaker.CreateToken(
 		user.Username,
-		s.config.AccessTokenDuration,
+		s.config.RefreshTokenDuration,
+		token2.WithScopes(scopes...),
 	)
 	if err != nil {
 		return models.LoginUserResponse{}, err
 	}
 
-	refreshToken, refreshPayload, err := s.tokenMaker.CreateToken(
+	roles, permissions, err := s.GetUserRoles(ctx, user.Username)
+	if err != nil {
+		return models.LoginUserResponse{}, err
+	}
+
+	// O ID do refresh token é o ID da sessão, que vai no access token
+	accessToken, accessPayload, err := s.tokenMaker.CreateToken(
 		user.Username,
-		s.config.RefreshTokenDuration,
+		s.config.AccessTokenDuration,
+		token2.WithSessionID(refreshPayload.ID),
+		token2.WithRoles(roles, permissions),
+		token2.WithScopes(scopes...),
 	)
 	if err != nil {
 		return models.LoginUserResponse{}, err
 	}
 
-	session, err := s.store.CreateSession(ctx, db.CreateSessionParams{
+	session, err := s.StartSession(ctx, db.CreateSessionParams{
 		ID:           refreshPayload.ID,
 		Username:     user.Username,
 		RefreshToken: refreshToken,
@@ -92,6 +175,7 @@ func (s *authService) LoginUser(ctx *gin.Context, req models.LoginUserRequest) (
 		ClientIp:     ctx.ClientIP(),
 		IsBlocked:    false,
 		ExpiresAt:    refreshPayload.ExpiredAt,
+		FamilyID:     refreshPayload.ID,
 	})
 	if err != nil {
 		return models.LoginUserResponse{}, err
@@ -103,6 +187,7 @@ func (s *authService) LoginUser(ctx *gin.Context, req models.LoginUserRequest) (
 		AccessTokenExpiresAt:  accessPayload.ExpiredAt,
 		RefreshToken:          refreshToken,
 		RefreshTokenExpiresAt: refreshPayload.ExpiredAt,
+		Scope:                 strings.Join(scopes, " "),
 		User:                  models.NewUserResponse(user),
 	}, nil
 }
@@ -111,15 +196,24 @@ func (s *authService) GetUser(ctx context.Context, username string) (db.User, er
 	return s.store.GetUser(ctx, username)
 }
 
+// RenewAccessToken rotaciona o refresh token: a sessão apresentada é marcada
+// como rotacionada e uma nova sessão da mesma família, com novo refresh token,
+// é criada. A família mantém a expiração do login. Apresentar de novo um
+// refresh token já rotacionado revoga a família inteira.
 func (s *authService) RenewAccessToken(ctx context.Context, payload *token2.Payload, refreshToken string) (models.RenewAccessTokenResponse, error) {
 	session, err := s.store.GetSession(ctx, payload.ID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return models.RenewAccessTokenResponse{}, fmt.Errorf("session not found")
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.RenewAccessTokenResponse{}, ErrSessionNotFound
 		}
 		return models.RenewAccessTokenResponse{}, err
 	}
 
+	if session.IsRotated {
+		return models.RenewAccessTokenResponse{}, s.refreshTokenReused(ctx, session)
+	}
+
+	// Sessões encerradas por logout ou revogação não renovam mais
 	if session.IsBlocked {
 		return models.RenewAccessTokenResponse{}, fmt.Errorf("session blocked")
 	}
@@ -136,13 +230,289 @@ func (s *authService) RenewAccessToken(ctx context.Context, payload *token2.Payl
 		return models.RenewAccessTokenResponse{}, fmt.Errorf("session expired")
 	}
 
-	accessToken, accessPayload, err := s.tokenMaker.CreateToken(payload.Username, s.config.AccessTokenDuration)
+	// Os papéis são relidos a cada renovação, para que mudanças valham sem
+	// novo login
+	roles, permissions, err := s.GetUserRoles(ctx, payload.Username)
+	if err != nil {
+		return models.RenewAccessTokenResponse{}, err
+	}
+
+	newRefreshToken, refreshPayload, err := s.tokenMaker.CreateToken(payload.Username, time.Until(session.ExpiresAt),
+		token2.WithScopes(payload.Scopes...))
+	if err != nil {
+		return models.RenewAccessTokenResponse{}, err
+	}
+
+	accessToken, accessPayload, err := s.tokenMaker.CreateToken(payload.Username, s.config.AccessTokenDuration,
+		token2.WithSessionID(refreshPayload.ID), token2.WithRoles(roles, permissions), token2.WithScopes(payload.Scopes...))
+	if err != nil {
+		return models.RenewAccessTokenResponse{}, err
+	}
+
+	next, err := s.store.RotateSessionTx(ctx, db.RotateSessionTxParams{
+		SessionID: session.ID,
+		Next: db.CreateSessionParams{
+			ID:           refreshPayload.ID,
+			Username:     session.Username,
+			RefreshToken: newRefreshToken,
+			UserAgent:    session.UserAgent,
+			ClientIp:     session.ClientIp,
+			ExpiresAt:    refreshPayload.ExpiredAt,
+			FamilyID:     session.FamilyID,
+		},
+	})
 	if err != nil {
+		// Outra renovação com o mesmo refresh token chegou antes
+		if errors.Is(err, db.ErrSessionRotated) {
+			return models.RenewAccessTokenResponse{}, s.refreshTokenReused(ctx, session)
+		}
 		return models.RenewAccessTokenResponse{}, err
 	}
 
 	return models.RenewAccessTokenResponse{
-		AccessToken:          accessToken,
-		AccessTokenExpiresAt: accessPayload.ExpiredAt,
+		SessionID:             next.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessPayload.ExpiredAt,
+		RefreshToken:          newRefreshToken,
+		RefreshTokenExpiresAt: refreshPayload.ExpiredAt,
 	}, nil
 }
+
+// refreshTokenReused revoga a família de uma sessão cujo refresh token foi
+// reutilizado: não há como saber se quem o apresentou é o usuário ou quem o
+// roubou, então ambos perdem a sessão.
+func (s *authService) refreshTokenReused(ctx context.Context, session db.Session) error {
+	log.Printf("renewAccessToken: refresh token reused for session %s of %s, revoking family %s",
+		session.ID, session.Username, session.FamilyID)
+	if _, err := s.revokeFamily(ctx, session.FamilyID, "refresh token reuse"); err != nil {
+		return fmt.Errorf("RenewAccessToken: %w", err)
+	}
+	return ErrRefreshTokenReused
+}
+
+// GetUserRoles retorna os papéis do usuário e a união, sem repetições, das
+// permissões que eles concedem.
+func (s *authService) GetUserRoles(ctx context.Context, username string) ([]string, []string, error) {
+	userRoles, err := s.store.ListUserRoles(ctx, username)
+	if err != nil {
+		return nil, nil, fmt.Errorf("GetUserRoles: %w", err)
+	}
+
+	roles := make([]string, 0, len(userRoles))
+	var permissions []string
+	for _, role := range userRoles {
+		roles = append(roles, role.Name)
+		permissions = append(permissions, role.Permissions...)
+	}
+	slices.Sort(permissions)
+	return roles, slices.Compact(permissions), nil
+}
+
+func (s *authService) AddUserRole(ctx context.Context, username, role string) error {
+	if _, err := s.store.GetUser(ctx, username); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if _, err := s.store.GetRole(ctx, role); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrRoleNotFound
+		}
+		return err
+	}
+
+	return s.store.AddUserRole(ctx, db.AddUserRoleParams{Username: username, Role: role})
+}
+
+func (s *authService) RemoveUserRole(ctx context.Context, username, role string) error {
+	removed, err := s.store.RemoveUserRole(ctx, db.RemoveUserRoleParams{Username: username, Role: role})
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrRoleNotFound
+	}
+	return nil
+}
+
+// StartSession grava a sessão de um login respeitando o limite de sessões
+// ativas do usuário (users.max_sessions ou SESSION_MAX_PER_USER). Com
+// SESSION_LIMIT_POLICY=evict_oldest, as sessões encerradas para abrir espaço
+// têm os tokens revogados.
+func (s *authService) StartSession(ctx context.Context, arg db.CreateSessionParams) (db.Session, error) {
+	result, err := s.store.CreateSessionTx(ctx, db.CreateSessionTxParams{
+		Session:     arg,
+		MaxSessions: s.config.SessionMaxPerUser,
+		EvictOldest: s.config.SessionLimitPolicy == util.SessionLimitEvictOldest,
+	})
+	if err != nil {
+		return db.Session{}, fmt.Errorf("StartSession: %w", err)
+	}
+
+	for _, evicted := range result.Evicted {
+		if _, err := s.revokeFamily(ctx, evicted.FamilyID, "session limit"); err != nil {
+			return db.Session{}, fmt.Errorf("StartSession: %w", err)
+		}
+	}
+	return result.Session, nil
+}
+
+// ResolveScopes interpreta o parâmetro scope (OAuth2, separado por espaços)
+// pedido no login. Sem escopos pedidos, concede todos os de TOKEN_SCOPES;
+// pedir um escopo fora de TOKEN_SCOPES é erro.
+func (s *authService) ResolveScopes(requested string) ([]string, error) {
+	scopes := strings.Fields(requested)
+	if len(scopes) == 0 {
+		return s.config.TokenScopes, nil
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(s.config.TokenScopes, scope) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+		}
+	}
+	slices.Sort(scopes)
+	return slices.Compact(scopes), nil
+}
+
+// RevokeToken revoga um access ou refresh token até ele expirar. Revogar um
+// refresh token derruba também os access tokens da sessão, pois o ID dele é o
+// da sessão. Tokens já revogados ou expirados não são erro.
+func (s *authService) RevokeToken(ctx context.Context, rawToken, reason string) ([]uuid.UUID, error) {
+	payload, err := s.tokenMaker.VerifyToken(rawToken)
+	if err != nil {
+		if errors.Is(err, token2.ErrTokenRevoked) || errors.Is(err, token2.ErrTokenExpired) {
+			return []uuid.UUID{}, nil
+		}
+		return nil, err
+	}
+
+	if err := s.revocations.Revoke(ctx, payload.ID, payload.ExpiredAt, reason); err != nil {
+		return nil, fmt.Errorf("RevokeToken: %w", err)
+	}
+	return []uuid.UUID{payload.ID}, nil
+}
+
+// RevokeSession bloqueia a sessão e as demais da família (as anteriores e
+// posteriores à rotação do refresh token), impedindo novas renovações, e
+// revoga os refresh e access tokens já emitidos por elas.
+func (s *authService) RevokeSession(ctx context.Context, sessionID uuid.UUID, reason string) ([]uuid.UUID, error) {
+	session, err := s.store.GetSession(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("RevokeSession: %w", err)
+	}
+
+	revoked, err := s.revokeFamily(ctx, session.FamilyID, reason)
+	if err != nil {
+		return nil, fmt.Errorf("RevokeSession: %w", err)
+	}
+	return revoked, nil
+}
+
+// RevokeUser bloqueia e revoga todas as sessões ativas do usuário.
+func (s *authService) RevokeUser(ctx context.Context, username, reason string) ([]uuid.UUID, error) {
+	if _, err := s.store.GetUser(ctx, username); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("RevokeUser: %w", err)
+	}
+
+	sessions, err := s.store.ListSessions(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("RevokeUser: %w", err)
+	}
+	if _, err := s.store.BlockUserSessions(ctx, username); err != nil {
+		return nil, fmt.Errorf("RevokeUser: %w", err)
+	}
+
+	revoked := make([]uuid.UUID, 0, len(sessions))
+	for _, session := range sessions {
+		if err := s.revokeSession(ctx, session, reason); err != nil {
+			return nil, fmt.Errorf("RevokeUser: %w", err)
+		}
+		revoked = append(revoked, session.ID)
+	}
+	return revoked, nil
+}
+
+// Logout encerra a sessão do access token usado na requisição.
+func (s *authService) Logout(ctx context.Context, sessionID uuid.UUID) ([]uuid.UUID, error) {
+	return s.RevokeSession(ctx, sessionID, "logout")
+}
+
+// LogoutAll encerra todas as sessões do usuário, em todos os dispositivos.
+func (s *authService) LogoutAll(ctx context.Context, username string) ([]uuid.UUID, error) {
+	return s.RevokeUser(ctx, username, "logout-all")
+}
+
+// ListActiveSessions retorna uma página das sessões ativas do usuário (uma
+// por login, sem as rotacionadas) e o total delas.
+func (s *authService) ListActiveSessions(ctx context.Context, username string, pageID, pageSize int32) ([]db.ListActiveSessionsRow, int64, error) {
+	sessions, err := s.store.ListActiveSessions(ctx, db.ListActiveSessionsParams{
+		Username: username,
+		Limit:    pageSize,
+		Offset:   (pageID - 1) * pageSize,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("ListActiveSessions: %w", err)
+	}
+
+	total, err := s.store.CountActiveSessions(ctx, username)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ListActiveSessions: %w", err)
+	}
+	return sessions, total, nil
+}
+
+// RevokeUserSession encerra uma sessão do usuário. Sessões de outro usuário
+// são tratadas como inexistentes.
+func (s *authService) RevokeUserSession(ctx context.Context, username string, sessionID uuid.UUID, reason string) ([]uuid.UUID, error) {
+	session, err := s.store.GetSession(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("RevokeUserSession: %w", err)
+	}
+	if session.Username != username {
+		return nil, ErrSessionNotFound
+	}
+
+	revoked, err := s.revokeFamily(ctx, session.FamilyID, reason)
+	if err != nil {
+		return nil, fmt.Errorf("RevokeUserSession: %w", err)
+	}
+	return revoked, nil
+}
+
+// revokeFamily bloqueia e revoga todas as sessões de uma família.
+func (s *authService) revokeFamily(ctx context.Context, familyID uuid.UUID, reason string) ([]uuid.UUID, error) {
+	sessions, err := s.store.ListSessionFamily(ctx, familyID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.store.BlockSessionFamily(ctx, familyID); err != nil {
+		return nil, err
+	}
+
+	revoked := make([]uuid.UUID, 0, len(sessions))
+	for _, session := range sessions {
+		if err := s.revokeSession(ctx, session, reason); err != nil {
+			return nil, err
+		}
+		revoked = append(revoked, session.ID)
+	}
+	return revoked, nil
+}
+
+// revokeSession coloca o ID da sessão na lista de revogação. Um access token
+// renovado pouco antes de a sessão expirar vale até AccessTokenDuration depois
+// dela, então a entrada precisa durar esse tempo a mais.
+func (s *authService) revokeSession(ctx context.Context, session db.Session, reason string) error {
+	return s.revocations.Revoke(ctx, session.ID, session.ExpiresAt.Add(s.config.AccessTokenDuration), reason)
+}
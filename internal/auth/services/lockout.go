@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	db "api--sigacore-gateway/internal/db/sqlc"
+	"api--sigacore-gateway/internal/util"
+)
+
+// ErrInvalidCredentials é a única resposta a um login com usuário ou senha
+// errados: não revela se o usuário existe.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// LoginLockedError indica que o usuário ou o IP está bloqueado por excesso de
+// falhas de login. A mensagem é a mesma exista o usuário ou não.
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return "too many failed login attempts, try again later"
+}
+
+// _dummyPasswordHash é comparado quando o usuário não existe, para que o tempo
+// de resposta seja o mesmo de uma senha errada.
+var _dummyPasswordHash = sync.OnceValue(func() string {
+	hash, err := util.HashPassword("sigacore-dummy-password")
+	if err != nil {
+		log.Printf("authenticate: cannot hash dummy password: %v", err)
+	}
+	return hash
+})
+
+func loginUserKey(username string) string {
+	return "user:" + username
+}
+
+func loginIPKey(clientIP string) string {
+	return "ip:" + clientIP
+}
+
+// Authenticate confere usuário e senha com proteção contra força bruta: as
+// falhas são contadas por usuário e por IP e, passado o limite
+// (LOGIN_LOCKOUT_THRESHOLD e LOGIN_LOCKOUT_IP_THRESHOLD), cada nova falha
+// bloqueia a chave por um tempo que dobra a cada vez. Usuário inexistente,
+// fora da whitelist (is_whitelisted) e senha errada retornam o mesmo
+// ErrInvalidCredentials e contam como falha.
+func (s *authService) Authenticate(ctx context.Context, username, password, clientIP string) (db.User, error) {
+	userKey, ipKey := loginUserKey(username), loginIPKey(clientIP)
+
+	if err := s.checkLoginLock(ctx, userKey, ipKey); err != nil {
+		return db.User{}, err
+	}
+
+	user, err := s.store.GetUser(ctx, username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return db.User{}, fmt.Errorf("Authenticate: %w", err)
+	}
+	found := err == nil
+
+	hash := user.HashedPassword
+	if !found {
+		hash = _dummyPasswordHash()
+	}
+	if err := util.VerifyPassword(password, hash); err != nil || !found || !user.IsWhitelisted {
+		s.recordLoginFailure(ctx, userKey, s.config.LoginLockoutThreshold)
+		s.recordLoginFailure(ctx, ipKey, s.config.LoginLockoutIPThreshold)
+		return db.User{}, ErrInvalidCredentials
+	}
+
+	// O IP não é zerado: um atacante com uma conta válida poderia usá-la para
+	// continuar tentando senhas de outros usuários
+	if _, err := s.store.ResetLoginFailures(ctx, userKey); err != nil {
+		log.Printf("authenticate: cannot reset failures of %s: %v", userKey, err)
+	}
+	return user, nil
+}
+
+// UnlockLogin remove o bloqueio e as falhas de um usuário ou de um IP.
+// Retorna false se não havia nada a remover.
+func (s *authService) UnlockLogin(ctx context.Context, username, clientIP string) (bool, error) {
+	key := loginUserKey(username)
+	if clientIP != "" {
+		key = loginIPKey(clientIP)
+	}
+
+	removed, err := s.store.ResetLoginFailures(ctx, key)
+	if err != nil {
+		return false, fmt.Errorf("UnlockLogin: %w", err)
+	}
+	return removed > 0, nil
+}
+
+// checkLoginLock retorna um *LoginLockedError se alguma das chaves estiver
+// bloqueada.
+func (s *authService) checkLoginLock(ctx context.Context, keys ...string) error {
+	lock, err := s.store.GetLoginLock(ctx, keys)
+	if err != nil {
+		return fmt.Errorf("checkLoginLock: %w", err)
+	}
+	if lock.LockedUntil.After(lock.Now) {
+		return &LoginLockedError{RetryAfter: lock.LockedUntil.Sub(lock.Now)}
+	}
+	return nil
+}
+
+// recordLoginFailure conta a falha e, a partir de threshold falhas, bloqueia
+// a chave. Um erro aqui não muda a resposta do login, só é registrado.
+func (s *authService) recordLoginFailure(ctx context.Context, key string, threshold int) {
+	failures, err := s.store.RecordLoginFailure(ctx, db.RecordLoginFailureParams{
+		Key:      key,
+		WindowUs: s.config.LoginFailureWindow.Microseconds(),
+	})
+	if err != nil {
+		log.Printf("authenticate: cannot record failure of %s: %v", key, err)
+		return
+	}
+
+	lock := lockoutDuration(int(failures), threshold, s.config.LoginLockoutBase, s.config.LoginLockoutMax)
+	if lock == 0 {
+		return
+	}
+	if err := s.store.LockLogin(ctx, db.LockLoginParams{LockUs: lock.Microseconds(), Key: key}); err != nil {
+		log.Printf("authenticate: cannot lock %s: %v", key, err)
+	}
+}
+
+// lockoutDuration é o bloqueio após failures falhas: nenhum antes de
+// threshold, base na falha threshold e o dobro a cada falha seguinte, até
+// maxLock.
+func lockoutDuration(failures, threshold int, base, maxLock time.Duration) time.Duration {
+	if failures < threshold {
+		return 0
+	}
+
+	lock := base
+	for i := threshold; i < failures && lock < maxLock; i++ {
+		lock *= 2
+	}
+	return min(lock, maxLock)
+}
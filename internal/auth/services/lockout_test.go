@@ -0,0 +1,28 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 1, want: 0},
+		{failures: 4, want: 0},
+		{failures: 5, want: 30 * time.Second},
+		{failures: 6, want: time.Minute},
+		{failures: 7, want: 2 * time.Minute},
+		{failures: 11, want: 32 * time.Minute},
+		{failures: 12, want: time.Hour},
+		{failures: 1000, want: time.Hour},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.want, lockoutDuration(tt.failures, 5, 30*time.Second, time.Hour), tt.failures)
+	}
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"api--sigacore-gateway/internal/auth/models"
+	db "api--sigacore-gateway/internal/db/sqlc"
+	token2 "api--sigacore-gateway/internal/token"
+	"api--sigacore-gateway/internal/util"
+)
+
+var (
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	ErrMFANotEnabled     = errors.New("two-factor authentication not enabled")
+	// ErrMFANotPending indica que não há cadastro TOTP para confirmar
+	ErrMFANotPending = db.ErrTOTPNotPending
+	// ErrInvalidMFACode cobre código errado, expirado ou já usado
+	ErrInvalidMFACode = errors.New("invalid two-factor code")
+	// ErrMFADisabled indica que MFA_SECRET_KEY não está definida
+	ErrMFADisabled = errors.New("two-factor authentication is disabled")
+)
+
+// EnrollTOTP gera um novo segredo TOTP, pendente até ConfirmTOTP. Chamar de
+// novo antes da confirmação troca o segredo.
+func (s *authService) EnrollTOTP(ctx context.Context, username string) (models.EnrollTOTPResponse, error) {
+	if !s.config.MFAEnabled() {
+		return models.EnrollTOTPResponse{}, ErrMFADisabled
+	}
+
+	secret, err := util.GenerateTOTPSecret()
+	if err != nil {
+		return models.EnrollTOTPResponse{}, err
+	}
+
+	encrypted, err := util.EncryptSecret([]byte(s.config.MFASecretKey), secret, username)
+	if err != nil {
+		return models.EnrollTOTPResponse{}, err
+	}
+
+	_, err = s.store.CreateTOTP(ctx, db.CreateTOTPParams{Username: username, Secret: encrypted})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.EnrollTOTPResponse{}, ErrMFAAlreadyEnabled
+		}
+		return models.EnrollTOTPResponse{}, fmt.Errorf("EnrollTOTP: %w", err)
+	}
+
+	return models.EnrollTOTPResponse{
+		Secret:          secret,
+		ProvisioningURI: util.TOTPProvisioningURI(s.config.MFAIssuer, username, secret),
+	}, nil
+}
+
+// ConfirmTOTP ativa o cadastro pendente com um código do app autenticador e
+// gera os códigos de recuperação, que só são exibidos nesta resposta.
+func (s *authService) ConfirmTOTP(ctx context.Context, username, code string) (models.RecoveryCodesResponse, error) {
+	totp, err := s.store.GetTOTP(ctx, username)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.RecoveryCodesResponse{}, ErrMFANotPending
+		}
+		return models.RecoveryCodesResponse{}, fmt.Errorf("ConfirmTOTP: %w", err)
+	}
+	if totp.IsEnabled {
+		return models.RecoveryCodesResponse{}, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := s.totpSecret(totp)
+	if err != nil {
+		return models.RecoveryCodesResponse{}, fmt.Errorf("ConfirmTOTP: %w", err)
+	}
+	step, ok := util.VerifyTOTP(secret, code, time.Now())
+	if !ok {
+		return models.RecoveryCodesResponse{}, ErrInvalidMFACode
+	}
+
+	codes := make([]string, s.config.MFARecoveryCodes)
+	hashes := make([]string, len(codes))
+	for i := range codes {
+		if codes[i], err = util.GenerateRecoveryCode(); err != nil {
+			return models.RecoveryCodesResponse{}, err
+		}
+		hashes[i] = util.HashRecoveryCode(codes[i])
+	}
+
+	err = s.store.EnableTOTPTx(ctx, db.EnableTOTPTxParams{Username: username, Step: step, CodeHashes: hashes})
+	if err != nil {
+		if errors.Is(err, db.ErrTOTPNotPending) {
+			return models.RecoveryCodesResponse{}, ErrMFANotPending
+		}
+		return models.RecoveryCodesResponse{}, fmt.Errorf("ConfirmTOTP: %w", err)
+	}
+	return models.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableTOTP desliga o segundo fator. Exige um código válido, para que um
+// access token vazado não baste para remover a proteção.
+func (s *authService) DisableTOTP(ctx context.Context, username, code, clientIP string) error {
+	if err := s.checkSecondFactor(ctx, username, code, clientIP); err != nil {
+		return err
+	}
+
+	if _, err := s.store.DisableTOTPTx(ctx, username); err != nil {
+		return fmt.Errorf("DisableTOTP: %w", err)
+	}
+	return nil
+}
+
+// MFAEnabled diz se o login do usuário exige segundo fator. Um cadastro
+// ainda não confirmado não conta.
+func (s *authService) MFAEnabled(ctx context.Context, username string) (bool, error) {
+	totp, err := s.store.GetTOTP(ctx, username)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("MFAEnabled: %w", err)
+	}
+	return totp.IsEnabled, nil
+}
+
+// CreateMFAChallenge emite o token de desafio entregue no lugar da sessão a
+// quem acertou a senha. Os escopos pedidos no login seguem no desafio.
+func (s *authService) CreateMFAChallenge(username string, scopes []string) (models.MFAChallengeResponse, error) {
+	challenge, payload, err := s.mfaMaker.CreateToken(username, s.config.MFAChallengeDuration,
+		token2.WithScopes(scopes...))
+	if err != nil {
+		return models.MFAChallengeResponse{}, fmt.Errorf("CreateMFAChallenge: %w", err)
+	}
+
+	return models.MFAChallengeResponse{
+		MFARequired:       true,
+		MFAToken:          challenge,
+		MFATokenExpiresAt: payload.ExpiredAt,
+	}, nil
+}
+
+// VerifyMFAChallenge confere o token de desafio e o código (TOTP ou de
+// recuperação) e retorna o payload do desafio, com usuário e escopos para
+// criar a sessão. O desafio é revogado ao ser usado.
+func (s *authService) VerifyMFAChallenge(ctx context.Context, challenge, code, clientIP string) (*token2.Payload, error) {
+	payload, err := s.mfaMaker.VerifyToken(challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkSecondFactor(ctx, payload.Username, code, clientIP); err != nil {
+		return nil, err
+	}
+
+	if err := s.revocations.Revoke(ctx, payload.ID, payload.ExpiredAt, "mfa challenge used"); err != nil {
+		return nil, fmt.Errorf("VerifyMFAChallenge: %w", err)
+	}
+	return payload, nil
+}
+
+// checkSecondFactor confere o código com a mesma proteção contra força bruta
+// do login: as falhas contam para o usuário e para o IP.
+func (s *authService) checkSecondFactor(ctx context.Context, username, code, clientIP string) error {
+	userKey, ipKey := loginUserKey(username), loginIPKey(clientIP)
+	if err := s.checkLoginLock(ctx, userKey, ipKey); err != nil {
+		return err
+	}
+
+	err := s.verifySecondFactor(ctx, username, code)
+	if errors.Is(err, ErrInvalidMFACode) {
+		s.recordLoginFailure(ctx, userKey, s.config.LoginLockoutThreshold)
+		s.recordLoginFailure(ctx, ipKey, s.config.LoginLockoutIPThreshold)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.store.ResetLoginFailures(ctx, userKey); err != nil {
+		log.Printf("checkSecondFactor: cannot reset failures of %s: %v", userKey, err)
+	}
+	return nil
+}
+
+// verifySecondFactor aceita um código TOTP ainda não usado ou um código de
+// recuperação, que é consumido.
+func (s *authService) verifySecondFactor(ctx context.Context, username, code string) error {
+	totp, err := s.store.GetTOTP(ctx, username)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrMFANotEnabled
+		}
+		return fmt.Errorf("verifySecondFactor: %w", err)
+	}
+	if !totp.IsEnabled {
+		return ErrMFANotEnabled
+	}
+
+	if len(code) == util.TOTPDigits {
+		secret, err := s.totpSecret(totp)
+		if err != nil {
+			return fmt.Errorf("verifySecondFactor: %w", err)
+		}
+		step, ok := util.VerifyTOTP(secret, code, time.Now())
+		if !ok {
+			return ErrInvalidMFACode
+		}
+		// O UPDATE só passa se o passo for posterior ao último usado, o que
+		// também resolve dois logins simultâneos com o mesmo código
+		used, err := s.store.UseTOTPStep(ctx, db.UseTOTPStepParams{Step: step, Username: username})
+		if err != nil {
+			return fmt.Errorf("verifySecondFactor: %w", err)
+		}
+		if used == 0 {
+			return ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	used, err := s.store.UseRecoveryCode(ctx, db.UseRecoveryCodeParams{
+		Username: username,
+		CodeHash: util.HashRecoveryCode(code),
+	})
+	if err != nil {
+		return fmt.Errorf("verifySecondFactor: %w", err)
+	}
+	if used == 0 {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// totpSecret decifra o segredo TOTP guardado no banco. O usuário é o dado
+// associado da cifragem: um segredo copiado para outra linha não decifra.
+// Sem MFA_SECRET_KEY, só os códigos de recuperação continuam valendo.
+func (s *authService) totpSecret(totp db.UserTotp) (string, error) {
+	if !s.config.MFAEnabled() {
+		return "", ErrMFADisabled
+	}
+	return util.DecryptSecret([]byte(s.config.MFASecretKey), totp.Secret, totp.Username)
+}
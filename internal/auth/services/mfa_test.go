@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+
+	db "api--sigacore-gateway/internal/db/sqlc"
+	"api--sigacore-gateway/internal/shared/revocation"
+	token2 "api--sigacore-gateway/internal/token"
+	"api--sigacore-gateway/internal/util"
+)
+
+// fakeMFAStore guarda em memória só o que o fluxo de segundo fator usa. Os
+// demais métodos do Store ficam nil e entram em pânico se chamados.
+type fakeMFAStore struct {
+	db.Store
+
+	mu       sync.Mutex
+	totp     map[string]db.UserTotp
+	codes    map[string]map[string]bool // usuário -> hash -> usado
+	failures map[string]int32
+	revoked  int
+}
+
+func newFakeMFAStore() *fakeMFAStore {
+	return &fakeMFAStore{
+		totp:     make(map[string]db.UserTotp),
+		codes:    make(map[string]map[string]bool),
+		failures: make(map[string]int32),
+	}
+}
+
+func (f *fakeMFAStore) CreateTOTP(_ context.Context, arg db.CreateTOTPParams) (db.UserTotp, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.totp[arg.Username].IsEnabled {
+		return db.UserTotp{}, pgx.ErrNoRows
+	}
+	totp := db.UserTotp{Username: arg.Username, Secret: arg.Secret}
+	f.totp[arg.Username] = totp
+	return totp, nil
+}
+
+func (f *fakeMFAStore) GetTOTP(_ context.Context, username string) (db.UserTotp, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	totp, ok := f.totp[username]
+	if !ok {
+		return db.UserTotp{}, pgx.ErrNoRows
+	}
+	return totp, nil
+}
+
+func (f *fakeMFAStore) EnableTOTPTx(_ context.Context, arg db.EnableTOTPTxParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	totp, ok := f.totp[arg.Username]
+	if !ok || totp.IsEnabled {
+		return db.ErrTOTPNotPending
+	}
+	totp.IsEnabled = true
+	totp.LastUsedStep = arg.Step
+	f.totp[arg.Username] = totp
+
+	f.codes[arg.Username] = make(map[string]bool)
+	for _, hash := range arg.CodeHashes {
+		f.codes[arg.Username][hash] = false
+	}
+	return nil
+}
+
+func (f *fakeMFAStore) UseTOTPStep(_ context.Context, arg db.UseTOTPStepParams) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	totp := f.totp[arg.Username]
+	if !totp.IsEnabled || totp.LastUsedStep >= arg.Step {
+		return 0, nil
+	}
+	totp.LastUsedStep = arg.Step
+	f.totp[arg.Username] = totp
+	return 1, nil
+}
+
+func (f *fakeMFAStore) UseRecoveryCode(_ context.Context, arg db.UseRecoveryCodeParams) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	used, ok := f.codes[arg.Username][arg.CodeHash]
+	if !ok || used {
+		return 0, nil
+	}
+	f.codes[arg.Username][arg.CodeHash] = true
+	return 1, nil
+}
+
+func (f *fakeMFAStore) GetLoginLock(context.Context, []string) (db.GetLoginLockRow, error) {
+	return db.GetLoginLockRow{Now: time.Now()}, nil
+}
+
+func (f *fakeMFAStore) RecordLoginFailure(_ context.Context, arg db.RecordLoginFailureParams) (int32, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures[arg.Key]++
+	return f.failures[arg.Key], nil
+}
+
+func (f *fakeMFAStore) LockLogin(context.Context, db.LockLoginParams) error {
+	return nil
+}
+
+func (f *fakeMFAStore) ResetLoginFailures(_ context.Context, key string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.failures, key)
+	return 1, nil
+}
+
+func (f *fakeMFAStore) RevokeToken(context.Context, db.RevokeTokenParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.revoked++
+	return nil
+}
+
+func newTestMFAService(t *testing.T) (*authService, *fakeMFAStore) {
+	t.Helper()
+	config := util.Config{
+		TokenSymmetricKey:       "test_token_key_0123456789abcdefg",
+		TokenIssuer:             "sigacore-auth-test",
+		TokenAudience:           "sigacore",
+		AccessTokenDuration:     time.Minute,
+		LoginLockoutThreshold:   5,
+		LoginLockoutIPThreshold: 20,
+		LoginLockoutBase:        time.Second,
+		LoginLockoutMax:         time.Minute,
+		LoginFailureWindow:      time.Hour,
+		MFAIssuer:               "SigaCore",
+		MFAChallengeDuration:    time.Minute,
+		MFARecoveryCodes:        3,
+		MFASecretKey:            "test_mfa_key_0123456789abcdefghi",
+	}
+
+	store := newFakeMFAStore()
+	revocations := revocation.NewList(store, time.Hour)
+	tokenMaker, err := token2.NewMakerFromConfig(config)
+	require.NoError(t, err)
+	mfaMaker, err := token2.NewMFAMakerFromConfig(config)
+	require.NoError(t, err)
+
+	service := NewAuthService(store, token2.NewRevocationMaker(tokenMaker, revocations),
+		token2.NewRevocationMaker(mfaMaker, revocations), revocations, config)
+	return service.(*authService), store
+}
+
+// enrollTestUser cadastra e confirma o TOTP de username e retorna o segredo,
+// o passo usado na confirmação e os códigos de recuperação.
+func enrollTestUser(t *testing.T, service *authService, username string) (string, int64, []string) {
+	t.Helper()
+	ctx := context.Background()
+
+	enrollment, err := service.EnrollTOTP(ctx, username)
+	require.NoError(t, err)
+
+	step := util.TOTPStep(time.Now())
+	code, err := util.TOTPCode(enrollment.Secret, step)
+	require.NoError(t, err)
+	recovery, err := service.ConfirmTOTP(ctx, username, code)
+	require.NoError(t, err)
+
+	return enrollment.Secret, step, recovery.RecoveryCodes
+}
+
+func TestTOTPEnrollment(t *testing.T) {
+	service, store := newTestMFAService(t)
+	ctx := context.Background()
+
+	enrollment, err := service.EnrollTOTP(ctx, "alice")
+	require.NoError(t, err)
+	require.Contains(t, enrollment.ProvisioningURI, "secret="+enrollment.Secret)
+
+	// O banco só vê o segredo cifrado, amarrado ao usuário
+	stored := store.totp["alice"]
+	require.NotEqual(t, enrollment.Secret, stored.Secret)
+	require.NotContains(t, stored.Secret, enrollment.Secret)
+	secret, err := util.DecryptSecret([]byte(service.config.MFASecretKey), stored.Secret, "alice")
+	require.NoError(t, err)
+	require.Equal(t, enrollment.Secret, secret)
+
+	// Pendente até a confirmação
+	enabled, err := service.MFAEnabled(ctx, "alice")
+	require.NoError(t, err)
+	require.False(t, enabled)
+
+	_, err = service.ConfirmTOTP(ctx, "alice", "000000")
+	require.ErrorIs(t, err, ErrInvalidMFACode)
+
+	code, err := util.TOTPCode(enrollment.Secret, util.TOTPStep(time.Now()))
+	require.NoError(t, err)
+	recovery, err := service.ConfirmTOTP(ctx, "alice", code)
+	require.NoError(t, err)
+	require.Len(t, recovery.RecoveryCodes, service.config.MFARecoveryCodes)
+
+	enabled, err = service.MFAEnabled(ctx, "alice")
+	require.NoError(t, err)
+	require.True(t, enabled)
+
+	_, err = service.EnrollTOTP(ctx, "alice")
+	require.ErrorIs(t, err, ErrMFAAlreadyEnabled)
+	_, err = service.ConfirmTOTP(ctx, "alice", code)
+	require.ErrorIs(t, err, ErrMFAAlreadyEnabled)
+
+	// Segredo copiado da linha de outro usuário não decifra
+	_, err = service.EnrollTOTP(ctx, "bob")
+	require.NoError(t, err)
+	bob := store.totp["bob"]
+	bob.Secret = stored.Secret
+	store.totp["bob"] = bob
+	_, err = service.ConfirmTOTP(ctx, "bob", code)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrInvalidMFACode)
+}
+
+func TestVerifyMFAChallenge(t *testing.T) {
+	service, store := newTestMFAService(t)
+	ctx := context.Background()
+	secret, step, recoveryCodes := enrollTestUser(t, service, "alice")
+
+	newChallenge := func() string {
+		challenge, err := service.CreateMFAChallenge("alice", []string{"docs:read"})
+		require.NoError(t, err)
+		require.True(t, challenge.MFARequired)
+		return challenge.MFAToken
+	}
+
+	// O código da confirmação já foi usado
+	confirmCode, err := util.TOTPCode(secret, step)
+	require.NoError(t, err)
+	_, err = service.VerifyMFAChallenge(ctx, newChallenge(), confirmCode, "10.0.0.1")
+	require.ErrorIs(t, err, ErrInvalidMFACode)
+
+	// O passo seguinte (dentro da tolerância) vale uma vez
+	code, err := util.TOTPCode(secret, step+1)
+	require.NoError(t, err)
+	challenge := newChallenge()
+	payload, err := service.VerifyMFAChallenge(ctx, challenge, code, "10.0.0.1")
+	require.NoError(t, err)
+	require.Equal(t, "alice", payload.Username)
+	require.Equal(t, []string{"docs:read"}, payload.Scopes)
+	require.Equal(t, 1, store.revoked)
+
+	// Repetir o código, com outro desafio, é recusado por UseTOTPStep
+	_, err = service.VerifyMFAChallenge(ctx, newChallenge(), code, "10.0.0.1")
+	require.ErrorIs(t, err, ErrInvalidMFACode)
+
+	// O desafio usado foi revogado
+	_, err = service.VerifyMFAChallenge(ctx, challenge, recoveryCodes[2], "10.0.0.1")
+	require.ErrorIs(t, err, token2.ErrTokenRevoked)
+
+	// Código de recuperação vale uma vez; maiúsculas e espaços são ignorados
+	_, err = service.VerifyMFAChallenge(ctx, newChallenge(), strings.ToUpper(recoveryCodes[0])+" ", "10.0.0.1")
+	require.NoError(t, err)
+	_, err = service.VerifyMFAChallenge(ctx, newChallenge(), recoveryCodes[0], "10.0.0.1")
+	require.ErrorIs(t, err, ErrInvalidMFACode)
+	_, err = service.VerifyMFAChallenge(ctx, newChallenge(), recoveryCodes[1], "10.0.0.1")
+	require.NoError(t, err)
+
+	// As falhas contam para o bloqueio de login
+	require.Equal(t, int32(3), store.failures[loginIPKey("10.0.0.1")])
+}
+
+func TestMFAChallengeIsNotAccessToken(t *testing.T) {
+	service, _ := newTestMFAService(t)
+
+	challenge, err := service.CreateMFAChallenge("alice", nil)
+	require.NoError(t, err)
+
+	// Mesmas chaves, outra audience
+	_, err = service.tokenMaker.VerifyToken(challenge.MFAToken)
+	require.ErrorIs(t, err, token2.ErrTokenAudience)
+
+	payload, err := service.mfaMaker.VerifyToken(challenge.MFAToken)
+	require.NoError(t, err)
+	require.Equal(t, "alice", payload.Username)
+}
+
+func TestTOTPDisabled(t *testing.T) {
+	service, store := newTestMFAService(t)
+	ctx := context.Background()
+	_, _, recoveryCodes := enrollTestUser(t, service, "alice")
+
+	// Sem MFA_SECRET_KEY não há cadastro novo nem código TOTP, mas quem já
+	// tinha 2FA continua precisando do segundo fator
+	service.config.MFASecretKey = ""
+	_, err := service.EnrollTOTP(ctx, "bob")
+	require.ErrorIs(t, err, ErrMFADisabled)
+	require.NotContains(t, store.totp, "bob")
+
+	enabled, err := service.MFAEnabled(ctx, "alice")
+	require.NoError(t, err)
+	require.True(t, enabled)
+
+	challenge, err := service.CreateMFAChallenge("alice", nil)
+	require.NoError(t, err)
+	_, err = service.VerifyMFAChallenge(ctx, challenge.MFAToken, "123456", "10.0.0.1")
+	require.ErrorIs(t, err, ErrMFADisabled)
+	_, err = service.VerifyMFAChallenge(ctx, challenge.MFAToken, recoveryCodes[0], "10.0.0.1")
+	require.NoError(t, err)
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+
+	"api--sigacore-gateway/internal/auth/models"
+	db "api--sigacore-gateway/internal/db/sqlc"
+	"api--sigacore-gateway/internal/shared/revocation"
+	token2 "api--sigacore-gateway/internal/token"
+	"api--sigacore-gateway/internal/util"
+)
+
+const testPassword = "correct horse battery"
+
+// fakeSessionStore guarda em memória usuários e sessões e reproduz o limite
+// de CreateSessionTx. Os demais métodos do Store ficam nil e entram em pânico
+// se chamados.
+type fakeSessionStore struct {
+	db.Store
+
+	mu       sync.Mutex
+	users    map[string]db.User
+	sessions []db.Session
+	txCalls  []db.CreateSessionTxParams
+	revoked  []uuid.UUID
+}
+
+func (f *fakeSessionStore) GetUser(_ context.Context, username string) (db.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	user, ok := f.users[username]
+	if !ok {
+		return db.User{}, pgx.ErrNoRows
+	}
+	return user, nil
+}
+
+func (f *fakeSessionStore) GetTOTP(context.Context, string) (db.UserTotp, error) {
+	return db.UserTotp{}, pgx.ErrNoRows
+}
+
+func (f *fakeSessionStore) GetLoginLock(context.Context, []string) (db.GetLoginLockRow, error) {
+	return db.GetLoginLockRow{Now: time.Now()}, nil
+}
+
+func (f *fakeSessionStore) RecordLoginFailure(context.Context, db.RecordLoginFailureParams) (int32, error) {
+	return 1, nil
+}
+
+func (f *fakeSessionStore) ResetLoginFailures(context.Context, string) (int64, error) {
+	return 0, nil
+}
+
+func (f *fakeSessionStore) ListUserRoles(context.Context, string) ([]db.Role, error) {
+	return nil, nil
+}
+
+func (f *fakeSessionStore) CreateSessionTx(_ context.Context, arg db.CreateSessionTxParams) (db.CreateSessionTxResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.txCalls = append(f.txCalls, arg)
+
+	var result db.CreateSessionTxResult
+	var active []int
+	for i, session := range f.sessions {
+		if session.Username == arg.Session.Username && !session.IsBlocked {
+			active = append(active, i)
+		}
+	}
+	if arg.MaxSessions > 0 {
+		if excess := len(active) - arg.MaxSessions + 1; excess > 0 {
+			if !arg.EvictOldest {
+				return db.CreateSessionTxResult{}, db.ErrSessionLimitReached
+			}
+			for _, i := range active[:excess] {
+				f.sessions[i].IsBlocked = true
+				result.Evicted = append(result.Evicted, f.sessions[i])
+			}
+		}
+	}
+
+	result.Session = db.Session{
+		ID:        arg.Session.ID,
+		Username:  arg.Session.Username,
+		ExpiresAt: arg.Session.ExpiresAt,
+		FamilyID:  arg.Session.FamilyID,
+	}
+	f.sessions = append(f.sessions, result.Session)
+	return result, nil
+}
+
+func (f *fakeSessionStore) ListSessionFamily(_ context.Context, familyID uuid.UUID) ([]db.Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var family []db.Session
+	for _, session := range f.sessions {
+		if session.FamilyID == familyID {
+			family = append(family, session)
+		}
+	}
+	return family, nil
+}
+
+func (f *fakeSessionStore) BlockSessionFamily(_ context.Context, familyID uuid.UUID) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.sessions {
+		if f.sessions[i].FamilyID == familyID {
+			f.sessions[i].IsBlocked = true
+		}
+	}
+	return 1, nil
+}
+
+func (f *fakeSessionStore) RevokeToken(_ context.Context, arg db.RevokeTokenParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.revoked = append(f.revoked, arg.TokenID)
+	return nil
+}
+
+func newTestSessionService(t *testing.T, policy string) (*authService, *fakeSessionStore) {
+	t.Helper()
+	config := util.Config{
+		TokenSymmetricKey:       "test_token_key_0123456789abcdefg",
+		TokenIssuer:             "sigacore-auth-test",
+		TokenAudience:           "sigacore",
+		AccessTokenDuration:     time.Minute,
+		RefreshTokenDuration:    time.Hour,
+		LoginLockoutThreshold:   5,
+		LoginLockoutIPThreshold: 20,
+		LoginLockoutBase:        time.Second,
+		LoginLockoutMax:         time.Minute,
+		LoginFailureWindow:      time.Hour,
+		SessionMaxPerUser:       2,
+		SessionLimitPolicy:      policy,
+	}
+
+	hashedPassword, err := util.HashPassword(testPassword)
+	require.NoError(t, err)
+	store := &fakeSessionStore{users: map[string]db.User{
+		"alice": {Username: "alice", HashedPassword: hashedPassword, IsWhitelisted: true},
+		"bob":   {Username: "bob", HashedPassword: hashedPassword},
+	}}
+
+	revocations := revocation.NewList(store, time.Hour)
+	tokenMaker, err := token2.NewMakerFromConfig(config)
+	require.NoError(t, err)
+
+	service := NewAuthService(store, token2.NewRevocationMaker(tokenMaker, revocations), nil, revocations, config)
+	return service.(*authService), store
+}
+
+func loginUser(service *authService, username string) (models.LoginUserResponse, error) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/users/login", nil)
+	return service.LoginUser(c, models.LoginUserRequest{Username: username, Password: testPassword})
+}
+
+func TestLoginUserSessionLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run(util.SessionLimitReject, func(t *testing.T) {
+		service, store := newTestSessionService(t, util.SessionLimitReject)
+
+		for range 2 {
+			_, err := loginUser(service, "alice")
+			require.NoError(t, err)
+		}
+		_, err := loginUser(service, "alice")
+		require.ErrorIs(t, err, ErrSessionLimitReached)
+
+		require.Len(t, store.txCalls, 3)
+		for _, call := range store.txCalls {
+			require.Equal(t, 2, call.MaxSessions)
+			require.False(t, call.EvictOldest)
+		}
+		require.Len(t, store.sessions, 2)
+		require.Empty(t, store.revoked)
+	})
+
+	t.Run(util.SessionLimitEvictOldest, func(t *testing.T) {
+		service, store := newTestSessionService(t, util.SessionLimitEvictOldest)
+
+		var sessions []models.LoginUserResponse
+		for range 3 {
+			response, err := loginUser(service, "alice")
+			require.NoError(t, err)
+			sessions = append(sessions, response)
+		}
+
+		require.Len(t, store.txCalls, 3)
+		for _, call := range store.txCalls {
+			require.Equal(t, 2, call.MaxSessions)
+			require.True(t, call.EvictOldest)
+		}
+
+		// A sessão mais antiga foi encerrada e teve os tokens revogados
+		require.True(t, store.sessions[0].IsBlocked)
+		require.Equal(t, []uuid.UUID{sessions[0].SessionID}, store.revoked)
+		_, err := service.tokenMaker.VerifyToken(sessions[0].AccessToken)
+		require.ErrorIs(t, err, token2.ErrTokenRevoked)
+		_, err = service.tokenMaker.VerifyToken(sessions[2].AccessToken)
+		require.NoError(t, err)
+	})
+}
+
+func TestAuthenticateWhitelist(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service, store := newTestSessionService(t, util.SessionLimitReject)
+
+	// Fora da whitelist recebe a mesma resposta que uma senha errada
+	_, err := service.Authenticate(context.Background(), "bob", testPassword, "10.0.0.1")
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = service.Authenticate(context.Background(), "alice", "wrong password", "10.0.0.1")
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = loginUser(service, "bob")
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+	require.Empty(t, store.txCalls)
+
+	user, err := service.Authenticate(context.Background(), "alice", testPassword, "10.0.0.1")
+	require.NoError(t, err)
+	require.Equal(t, "alice", user.Username)
+}
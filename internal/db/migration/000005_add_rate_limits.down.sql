@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "idx_rate_limits_tat";
+
+DROP TABLE IF EXISTS "rate_limits";
@@ -0,0 +1,8 @@
+-- Estado do rate limiter distribuído (GCRA): "theoretical arrival time" por chave
+CREATE TABLE "rate_limits" (
+                               "key" varchar PRIMARY KEY,
+                               "tat" timestamptz NOT NULL
+);
+
+-- Acelera a limpeza de chaves cujo bucket já está cheio
+CREATE INDEX "idx_rate_limits_tat" ON "rate_limits" ("tat");
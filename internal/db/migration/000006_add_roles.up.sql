@@ -0,0 +1,22 @@
+-- Papéis e as permissões que cada um concede. A permissão "*" concede todas
+CREATE TABLE "roles" (
+                         "name" varchar PRIMARY KEY,
+                         "description" varchar NOT NULL DEFAULT '',
+                         "permissions" varchar[] NOT NULL DEFAULT '{}',
+                         "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "user_roles" (
+                              "username" varchar NOT NULL REFERENCES "users" ("username") ON DELETE CASCADE,
+                              "role" varchar NOT NULL REFERENCES "roles" ("name") ON DELETE CASCADE,
+                              "created_at" timestamptz NOT NULL DEFAULT (now()),
+                              PRIMARY KEY ("username", "role")
+);
+
+CREATE INDEX "idx_user_roles_role" ON "user_roles" ("role");
+
+-- Papéis iniciais. O primeiro admin é atribuído direto no banco:
+-- INSERT INTO user_roles (username, role) VALUES ('fulano', 'admin');
+INSERT INTO "roles" ("name", "description", "permissions") VALUES
+    ('admin', 'Acesso total', '{*}'),
+    ('user', 'Usuário comum', '{}');
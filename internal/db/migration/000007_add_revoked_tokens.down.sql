@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS "revoked_tokens_notify" ON "revoked_tokens";
+
+DROP FUNCTION IF EXISTS notify_revoked_token();
+
+DROP TABLE IF EXISTS "revoked_tokens";
@@ -0,0 +1,24 @@
+-- Tokens revogados antes de expirar. A linha só é necessária até expires_at,
+-- quando o token deixaria de valer de qualquer forma
+CREATE TABLE "revoked_tokens" (
+                                  "token_id" uuid PRIMARY KEY,
+                                  "expires_at" timestamptz NOT NULL,
+                                  "reason" varchar NOT NULL DEFAULT '',
+                                  "revoked_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX "idx_revoked_tokens_expires_at" ON "revoked_tokens" ("expires_at");
+
+-- Avisa as réplicas (LISTEN revoked_tokens) a cada revogação, com o payload
+-- "<token_id> <expires_at em segundos unix>"
+CREATE FUNCTION notify_revoked_token() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('revoked_tokens',
+        NEW.token_id::text || ' ' || ceil(extract(epoch FROM NEW.expires_at))::bigint::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "revoked_tokens_notify"
+    AFTER INSERT ON "revoked_tokens"
+    FOR EACH ROW EXECUTE FUNCTION notify_revoked_token();
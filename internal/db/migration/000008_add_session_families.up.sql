@@ -0,0 +1,10 @@
+-- Cada renovação gera uma sessão (e um refresh token) nova na mesma família e
+-- marca a anterior como rotacionada. Um refresh token rotacionado apresentado
+-- de novo indica roubo: a família inteira é revogada
+ALTER TABLE "sessions" ADD COLUMN "family_id" uuid;
+UPDATE "sessions" SET "family_id" = "id";
+ALTER TABLE "sessions" ALTER COLUMN "family_id" SET NOT NULL;
+
+ALTER TABLE "sessions" ADD COLUMN "is_rotated" boolean NOT NULL DEFAULT false;
+
+CREATE INDEX "idx_sessions_family_id" ON "sessions" ("family_id");
@@ -0,0 +1,3 @@
+-- Limite de sessões ativas do usuário. NULL usa SESSION_MAX_PER_USER e 0
+-- libera sessões ilimitadas
+ALTER TABLE "users" ADD COLUMN "max_sessions" integer CHECK ("max_sessions" >= 0);
@@ -0,0 +1,12 @@
+-- Falhas de login por chave ("user:<username>" ou "ip:<endereço>"). Depois de
+-- um limite de falhas, cada nova falha bloqueia a chave por um tempo que
+-- dobra a cada tentativa
+CREATE TABLE "login_attempts" (
+                                  "key" varchar PRIMARY KEY,
+                                  "failures" integer NOT NULL DEFAULT 0,
+                                  "locked_until" timestamptz NOT NULL DEFAULT (now()),
+                                  "last_failure_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+-- Acelera a limpeza das chaves sem falhas recentes
+CREATE INDEX "idx_login_attempts_last_failure_at" ON "login_attempts" ("last_failure_at");
@@ -0,0 +1,26 @@
+-- Segundo fator TOTP (RFC 6238). O segredo fica pendente (is_enabled = false)
+-- até o usuário confirmar um código do app autenticador. last_used_step é o
+-- último passo aceito: um código não vale duas vezes. secret é guardado
+-- cifrado (AES-256-GCM com MFA_SECRET_KEY), nunca em texto puro
+CREATE TABLE "user_totp" (
+                             "username" varchar PRIMARY KEY,
+                             "secret" varchar NOT NULL,
+                             "is_enabled" boolean NOT NULL DEFAULT false,
+                             "last_used_step" bigint NOT NULL DEFAULT 0,
+                             "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "user_totp" ADD FOREIGN KEY ("username") REFERENCES "users" ("username") ON DELETE CASCADE;
+
+-- Códigos de recuperação, guardados só como hash SHA-256. Cada um vale uma vez
+CREATE TABLE "recovery_codes" (
+                                  "id" bigserial PRIMARY KEY,
+                                  "username" varchar NOT NULL,
+                                  "code_hash" varchar NOT NULL,
+                                  "used_at" timestamptz,
+                                  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "recovery_codes" ADD FOREIGN KEY ("username") REFERENCES "users" ("username") ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX "idx_recovery_codes_username_code_hash" ON "recovery_codes" ("username", "code_hash");
@@ -0,0 +1,4 @@
+-- name: TryAdvisoryXactLock :one
+-- Tenta o advisory lock da transação sem esperar; ele é liberado no fim da
+-- transação
+SELECT pg_try_advisory_xact_lock(sqlc.arg(key)::bigint) AS locked;
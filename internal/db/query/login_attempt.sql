@@ -0,0 +1,37 @@
+-- name: RecordLoginFailure :one
+-- Conta uma falha de login. Falhas mais antigas que a janela não contam: a
+-- contagem recomeça
+INSERT INTO login_attempts AS la (
+    key,
+    failures,
+    last_failure_at
+) VALUES (
+    sqlc.arg(key), 1, now()
+)
+ON CONFLICT (key) DO UPDATE
+SET failures = CASE
+        WHEN la.last_failure_at < now() - sqlc.arg(window_us)::bigint * interval '1 microsecond' THEN 1
+        ELSE la.failures + 1
+    END,
+    last_failure_at = now()
+RETURNING failures;
+
+-- name: LockLogin :exec
+-- Bloqueia a chave; um bloqueio mais longo já em vigor é mantido
+UPDATE login_attempts
+SET locked_until = GREATEST(locked_until, now() + sqlc.arg(lock_us)::bigint * interval '1 microsecond')
+WHERE key = sqlc.arg(key);
+
+-- name: GetLoginLock :one
+SELECT COALESCE(max(locked_until), now())::timestamptz AS locked_until, now()::timestamptz AS now
+FROM login_attempts
+WHERE key = ANY(sqlc.arg(keys)::varchar[]);
+
+-- name: ResetLoginFailures :execrows
+DELETE FROM login_attempts
+WHERE key = $1;
+
+-- name: DeleteStaleLoginAttempts :execrows
+DELETE FROM login_attempts
+WHERE locked_until < now()
+  AND last_failure_at < now() - sqlc.arg(window_us)::bigint * interval '1 microsecond';
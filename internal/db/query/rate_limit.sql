@@ -0,0 +1,22 @@
+-- name: ConsumeRateLimit :one
+-- Aplica o GCRA de forma atômica: só grava o novo TAT se a requisição couber
+-- no limite. Sem linha retornada, a requisição foi negada.
+INSERT INTO rate_limits AS rl (
+    key,
+    tat
+) VALUES (
+    sqlc.arg(key), now() + sqlc.arg(emission_interval_us)::bigint * interval '1 microsecond'
+)
+ON CONFLICT (key) DO UPDATE
+SET tat = GREATEST(rl.tat, now()) + sqlc.arg(emission_interval_us)::bigint * interval '1 microsecond'
+WHERE GREATEST(rl.tat, now()) + sqlc.arg(emission_interval_us)::bigint * interval '1 microsecond'
+    - sqlc.arg(burst_offset_us)::bigint * interval '1 microsecond' <= now()
+RETURNING tat, now()::timestamptz AS now;
+
+-- name: GetRateLimit :one
+SELECT tat, now()::timestamptz AS now FROM rate_limits
+WHERE key = $1 LIMIT 1;
+
+-- name: DeleteStaleRateLimits :execrows
+DELETE FROM rate_limits
+WHERE tat < now();
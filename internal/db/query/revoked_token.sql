@@ -0,0 +1,16 @@
+-- name: RevokeToken :exec
+INSERT INTO revoked_tokens (
+    token_id,
+    expires_at,
+    reason
+) VALUES (
+             $1, $2, $3
+         ) ON CONFLICT (token_id) DO NOTHING;
+
+-- name: ListRevokedTokens :many
+SELECT token_id, expires_at FROM revoked_tokens
+WHERE expires_at > now();
+
+-- name: DeleteExpiredRevokedTokens :execrows
+DELETE FROM revoked_tokens
+WHERE expires_at <= now();
@@ -0,0 +1,34 @@
+-- name: CreateRole :one
+INSERT INTO roles (
+    name,
+    description,
+    permissions
+) VALUES (
+             $1, $2, $3
+         ) RETURNING *;
+
+-- name: GetRole :one
+SELECT * FROM roles
+WHERE name = $1 LIMIT 1;
+
+-- name: ListRoles :many
+SELECT * FROM roles
+ORDER BY name;
+
+-- name: AddUserRole :exec
+INSERT INTO user_roles (
+    username,
+    role
+) VALUES (
+             $1, $2
+         ) ON CONFLICT DO NOTHING;
+
+-- name: RemoveUserRole :execrows
+DELETE FROM user_roles
+WHERE username = $1 AND role = $2;
+
+-- name: ListUserRoles :many
+SELECT r.* FROM roles r
+JOIN user_roles ur ON ur.role = r.name
+WHERE ur.username = $1
+ORDER BY r.name;
@@ -6,11 +6,74 @@ INSERT INTO sessions (
     user_agent,
     client_ip,
     is_blocked,
-    expires_at
+    expires_at,
+    family_id
 ) VALUES (
-             $1, $2, $3, $4, $5, $6, $7
+             $1, $2, $3, $4, $5, $6, $7, $8
          ) RETURNING *;
 
 -- name: GetSession :one
 SELECT * FROM sessions
-WHERE id = $1 LIMIT 1;
\ No newline at end of file
+WHERE id = $1 LIMIT 1;
+
+-- name: ListSessions :many
+SELECT * FROM sessions
+WHERE username = $1 AND expires_at > now()
+ORDER BY created_at DESC;
+
+-- name: ListSessionFamily :many
+SELECT * FROM sessions
+WHERE family_id = $1
+ORDER BY created_at;
+
+-- name: BlockSession :exec
+UPDATE sessions
+SET is_blocked = true
+WHERE id = $1;
+
+-- name: BlockUserSessions :execrows
+UPDATE sessions
+SET is_blocked = true
+WHERE username = $1 AND NOT is_blocked;
+
+-- name: BlockSessionFamily :execrows
+UPDATE sessions
+SET is_blocked = true
+WHERE family_id = $1 AND NOT is_blocked;
+
+-- name: RotateSession :execrows
+UPDATE sessions
+SET is_rotated = true
+WHERE id = $1 AND NOT is_rotated AND NOT is_blocked;
+
+-- name: ListActiveSessions :many
+SELECT s.id, s.family_id, s.user_agent, s.client_ip, s.expires_at, s.created_at,
+       COALESCE(root.created_at, s.created_at)::timestamptz AS signed_in_at
+FROM sessions s
+LEFT JOIN sessions root ON root.id = s.family_id
+WHERE s.username = $1 AND s.expires_at > now() AND NOT s.is_blocked AND NOT s.is_rotated
+ORDER BY s.created_at DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: CountActiveSessions :one
+SELECT count(*) FROM sessions
+WHERE username = $1 AND expires_at > now() AND NOT is_blocked AND NOT is_rotated;
+
+-- name: DeleteExpiredSessions :execrows
+-- Apaga um lote de sessões expiradas há mais que o período de carência.
+-- SKIP LOCKED evita esperar por linhas presas em outra transação
+DELETE FROM sessions
+WHERE id IN (
+    SELECT id FROM sessions
+    WHERE expires_at < now() - sqlc.arg(grace_period_us)::bigint * interval '1 microsecond'
+    ORDER BY expires_at
+    LIMIT sqlc.arg(batch_size)::int
+    FOR UPDATE SKIP LOCKED
+);
+
+-- name: ListOldestActiveSessions :many
+SELECT * FROM sessions
+WHERE username = $1 AND expires_at > now() AND NOT is_blocked AND NOT is_rotated
+ORDER BY created_at
+LIMIT $2;
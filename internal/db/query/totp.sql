@@ -0,0 +1,54 @@
+-- name: CreateTOTP :one
+-- Grava um segredo pendente, substituindo um cadastro não confirmado. Não
+-- retorna linha se o TOTP já estiver ativo
+INSERT INTO user_totp AS t (
+    username,
+    secret
+) VALUES (
+    $1, $2
+)
+ON CONFLICT (username) DO UPDATE
+SET secret = EXCLUDED.secret,
+    last_used_step = 0,
+    created_at = now()
+WHERE NOT t.is_enabled
+RETURNING *;
+
+-- name: GetTOTP :one
+SELECT * FROM user_totp
+WHERE username = $1 LIMIT 1;
+
+-- name: EnableTOTP :execrows
+UPDATE user_totp
+SET is_enabled = true,
+    last_used_step = sqlc.arg(step)
+WHERE username = sqlc.arg(username)
+  AND NOT is_enabled;
+
+-- name: UseTOTPStep :execrows
+-- Consome o passo do código aceito. Falha (0 linhas) se o passo, ou um
+-- posterior, já foi usado
+UPDATE user_totp
+SET last_used_step = sqlc.arg(step)
+WHERE username = sqlc.arg(username)
+  AND is_enabled
+  AND last_used_step < sqlc.arg(step);
+
+-- name: DeleteTOTP :execrows
+DELETE FROM user_totp
+WHERE username = $1;
+
+-- name: CreateRecoveryCodes :execrows
+INSERT INTO recovery_codes (username, code_hash)
+SELECT sqlc.arg(username)::varchar, unnest(sqlc.arg(code_hashes)::varchar[]);
+
+-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes
+SET used_at = now()
+WHERE username = sqlc.arg(username)
+  AND code_hash = sqlc.arg(code_hash)
+  AND used_at IS NULL;
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE username = $1;
@@ -22,4 +22,11 @@ SET
     email = COALESCE(sqlc.narg(email), email)
 WHERE
     username = sqlc.arg(username)
-    RETURNING *;
\ No newline at end of file
+    RETURNING *;
+
+-- name: GetUserMaxSessionsForUpdate :one
+-- Trava a linha do usuário: logins simultâneos do mesmo usuário passam um
+-- por vez pela contagem de sessões
+SELECT max_sessions FROM users
+WHERE username = $1 LIMIT 1
+FOR UPDATE;
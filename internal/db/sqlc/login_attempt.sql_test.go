@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"api--sigacore-gateway/internal/util"
+)
+
+func TestLoginAttempts(t *testing.T) {
+	ctx := context.Background()
+	key := "user:" + util.RandomOwner()
+	window := time.Hour
+
+	for i := int32(1); i <= 3; i++ {
+		failures, err := testStore.RecordLoginFailure(ctx, RecordLoginFailureParams{Key: key, WindowUs: window.Microseconds()})
+		require.NoError(t, err)
+		require.Equal(t, i, failures)
+	}
+
+	lock, err := testStore.GetLoginLock(ctx, []string{key})
+	require.NoError(t, err)
+	require.False(t, lock.LockedUntil.After(lock.Now))
+
+	require.NoError(t, testStore.LockLogin(ctx, LockLoginParams{LockUs: time.Minute.Microseconds(), Key: key}))
+	// Um bloqueio mais curto não encurta o que está em vigor
+	require.NoError(t, testStore.LockLogin(ctx, LockLoginParams{LockUs: time.Second.Microseconds(), Key: key}))
+
+	lock, err = testStore.GetLoginLock(ctx, []string{key, "ip:" + util.RandomOwner()})
+	require.NoError(t, err)
+	require.WithinDuration(t, lock.Now.Add(time.Minute), lock.LockedUntil, 5*time.Second)
+
+	removed, err := testStore.ResetLoginFailures(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), removed)
+
+	removed, err = testStore.ResetLoginFailures(ctx, key)
+	require.NoError(t, err)
+	require.Zero(t, removed)
+
+	_, err = testStore.DeleteStaleLoginAttempts(ctx, window.Microseconds())
+	require.NoError(t, err)
+}
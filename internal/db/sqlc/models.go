@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Account struct {
@@ -26,6 +27,40 @@ type Entry struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+type LoginAttempt struct {
+	Key           string    `json:"key"`
+	Failures      int32     `json:"failures"`
+	LockedUntil   time.Time `json:"locked_until"`
+	LastFailureAt time.Time `json:"last_failure_at"`
+}
+
+type RateLimit struct {
+	Key string    `json:"key"`
+	Tat time.Time `json:"tat"`
+}
+
+type RecoveryCode struct {
+	ID        int64              `json:"id"`
+	Username  string             `json:"username"`
+	CodeHash  string             `json:"code_hash"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+type RevokedToken struct {
+	TokenID   uuid.UUID `json:"token_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Reason    string    `json:"reason"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+type Role struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
 type Session struct {
 	ID           uuid.UUID `json:"id"`
 	Username     string    `json:"username"`
@@ -35,6 +70,8 @@ type Session struct {
 	IsBlocked    bool      `json:"is_blocked"`
 	ExpiresAt    time.Time `json:"expires_at"`
 	CreatedAt    time.Time `json:"created_at"`
+	FamilyID     uuid.UUID `json:"family_id"`
+	IsRotated    bool      `json:"is_rotated"`
 }
 
 type Transfer struct {
@@ -47,11 +84,26 @@ type Transfer struct {
 }
 
 type User struct {
-	Username          string    `json:"username"`
-	HashedPassword    string    `json:"hashed_password"`
-	FullName          string    `json:"full_name"`
-	Email             string    `json:"email"`
-	PasswordChangedAt time.Time `json:"password_changed_at"`
-	CreatedAt         time.Time `json:"created_at"`
-	IsWhitelisted     bool      `json:"is_whitelisted"`
+	Username          string      `json:"username"`
+	HashedPassword    string      `json:"hashed_password"`
+	FullName          string      `json:"full_name"`
+	Email             string      `json:"email"`
+	PasswordChangedAt time.Time   `json:"password_changed_at"`
+	CreatedAt         time.Time   `json:"created_at"`
+	IsWhitelisted     bool        `json:"is_whitelisted"`
+	MaxSessions       pgtype.Int4 `json:"max_sessions"`
+}
+
+type UserRole struct {
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type UserTotp struct {
+	Username     string    `json:"username"`
+	Secret       string    `json:"secret"`
+	IsEnabled    bool      `json:"is_enabled"`
+	LastUsedStep int64     `json:"last_used_step"`
+	CreatedAt    time.Time `json:"created_at"`
 }
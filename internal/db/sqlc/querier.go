@@ -8,21 +8,74 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	AddUserRole(ctx context.Context, arg AddUserRoleParams) error
+	BlockSession(ctx context.Context, id uuid.UUID) error
+	BlockSessionFamily(ctx context.Context, familyID uuid.UUID) (int64, error)
+	BlockUserSessions(ctx context.Context, username string) (int64, error)
+	// Aplica o GCRA de forma atômica: só grava o novo TAT se a requisição couber
+	// no limite. Sem linha retornada, a requisição foi negada.
+	ConsumeRateLimit(ctx context.Context, arg ConsumeRateLimitParams) (ConsumeRateLimitRow, error)
+	CountActiveSessions(ctx context.Context, username string) (int64, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateRecoveryCodes(ctx context.Context, arg CreateRecoveryCodesParams) (int64, error)
+	CreateRole(ctx context.Context, arg CreateRoleParams) (Role, error)
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	// Grava um segredo pendente, substituindo um cadastro não confirmado. Não
+	// retorna linha se o TOTP já estiver ativo
+	CreateTOTP(ctx context.Context, arg CreateTOTPParams) (UserTotp, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	DeleteAccount(ctx context.Context, id int64) error
+	DeleteExpiredRevokedTokens(ctx context.Context) (int64, error)
+	// Apaga um lote de sessões expiradas há mais que o período de carência.
+	// SKIP LOCKED evita esperar por linhas presas em outra transação
+	DeleteExpiredSessions(ctx context.Context, arg DeleteExpiredSessionsParams) (int64, error)
+	DeleteRecoveryCodes(ctx context.Context, username string) error
+	DeleteStaleLoginAttempts(ctx context.Context, windowUs int64) (int64, error)
+	DeleteStaleRateLimits(ctx context.Context) (int64, error)
+	DeleteTOTP(ctx context.Context, username string) (int64, error)
+	EnableTOTP(ctx context.Context, arg EnableTOTPParams) (int64, error)
 	GetAccount(ctx context.Context, id int64) (Account, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
+	GetLoginLock(ctx context.Context, keys []string) (GetLoginLockRow, error)
+	GetRateLimit(ctx context.Context, key string) (GetRateLimitRow, error)
+	GetRole(ctx context.Context, name string) (Role, error)
 	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
+	GetTOTP(ctx context.Context, username string) (UserTotp, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	// Trava a linha do usuário: logins simultâneos do mesmo usuário passam um
+	// por vez pela contagem de sessões
+	GetUserMaxSessionsForUpdate(ctx context.Context, username string) (pgtype.Int4, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	ListActiveSessions(ctx context.Context, arg ListActiveSessionsParams) ([]ListActiveSessionsRow, error)
+	ListOldestActiveSessions(ctx context.Context, arg ListOldestActiveSessionsParams) ([]Session, error)
+	ListRevokedTokens(ctx context.Context) ([]ListRevokedTokensRow, error)
+	ListRoles(ctx context.Context) ([]Role, error)
+	ListSessionFamily(ctx context.Context, familyID uuid.UUID) ([]Session, error)
+	ListSessions(ctx context.Context, username string) ([]Session, error)
+	ListUserRoles(ctx context.Context, username string) ([]Role, error)
+	// Bloqueia a chave; um bloqueio mais longo já em vigor é mantido
+	LockLogin(ctx context.Context, arg LockLoginParams) error
+	// Conta uma falha de login. Falhas mais antigas que a janela não contam: a
+	// contagem recomeça
+	RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (int32, error)
+	RemoveUserRole(ctx context.Context, arg RemoveUserRoleParams) (int64, error)
+	ResetLoginFailures(ctx context.Context, key string) (int64, error)
+	RevokeToken(ctx context.Context, arg RevokeTokenParams) error
+	RotateSession(ctx context.Context, id uuid.UUID) (int64, error)
+	// Tenta o advisory lock da transação sem esperar; ele é liberado no fim da
+	// transação
+	TryAdvisoryXactLock(ctx context.Context, key int64) (bool, error)
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error)
+	// Consome o passo do código aceito. Falha (0 linhas) se o passo, ou um
+	// posterior, já foi usado
+	UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (int64, error)
 }
 
 var _ Querier = (*Queries)(nil)
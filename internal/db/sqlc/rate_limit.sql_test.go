@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/checkioname/simple-bank/util"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+)
+
+// consumeParams monta um limite de burst requisições com uma reposição a cada emission.
+func consumeParams(key string, emission time.Duration, burst int) ConsumeRateLimitParams {
+	return ConsumeRateLimitParams{
+		Key:                key,
+		EmissionIntervalUs: emission.Microseconds(),
+		BurstOffsetUs:      (emission * time.Duration(burst)).Microseconds(),
+	}
+}
+
+func TestConsumeRateLimit(t *testing.T) {
+	ctx := context.Background()
+	arg := consumeParams("test:"+util.RandomOwner(), time.Hour, 3)
+
+	for i := 0; i < 3; i++ {
+		row, err := testStore.ConsumeRateLimit(ctx, arg)
+		require.NoError(t, err)
+		require.True(t, row.Tat.After(row.Now))
+	}
+
+	_, err := testStore.ConsumeRateLimit(ctx, arg)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	state, err := testStore.GetRateLimit(ctx, arg.Key)
+	require.NoError(t, err)
+	require.WithinDuration(t, state.Now.Add(3*time.Hour), state.Tat, time.Minute)
+}
+
+func TestConsumeRateLimitConcurrent(t *testing.T) {
+	ctx := context.Background()
+	arg := consumeParams("test:"+util.RandomOwner(), time.Hour, 5)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := testStore.ConsumeRateLimit(ctx, arg)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return
+			}
+			require.NoError(t, err)
+
+			mu.Lock()
+			allowed++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, 5, allowed)
+}
+
+func TestDeleteStaleRateLimits(t *testing.T) {
+	ctx := context.Background()
+	key := "test:" + util.RandomOwner()
+
+	_, err := testStore.ConsumeRateLimit(ctx, consumeParams(key, time.Millisecond, 1))
+	require.NoError(t, err)
+
+	time.Sleep(10 * time.Millisecond)
+	deleted, err := testStore.DeleteStaleRateLimits(ctx)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, deleted, int64(1))
+
+	_, err = testStore.GetRateLimit(ctx, key)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevokedTokens(t *testing.T) {
+	ctx := context.Background()
+	active := RevokeTokenParams{TokenID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour), Reason: "test"}
+	expired := RevokeTokenParams{TokenID: uuid.New(), ExpiresAt: time.Now().Add(-time.Hour)}
+
+	for _, arg := range []RevokeTokenParams{active, expired, active} {
+		require.NoError(t, testStore.RevokeToken(ctx, arg))
+	}
+
+	rows, err := testStore.ListRevokedTokens(ctx)
+	require.NoError(t, err)
+	ids := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.TokenID)
+	}
+	require.Contains(t, ids, active.TokenID)
+	require.NotContains(t, ids, expired.TokenID)
+
+	deleted, err := testStore.DeleteExpiredRevokedTokens(ctx)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, deleted, int64(1))
+}
+
+func TestRevokedTokensNotify(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	payloads := make(chan string, 1)
+	go func() {
+		_ = testStore.Listen(ctx, "revoked_tokens", func(payload string) {
+			payloads <- payload
+		})
+	}()
+	// Dá tempo do LISTEN ser registrado antes do INSERT
+	time.Sleep(200 * time.Millisecond)
+
+	arg := RevokeTokenParams{TokenID: uuid.New(), ExpiresAt: time.Unix(1900000000, 0)}
+	require.NoError(t, testStore.RevokeToken(ctx, arg))
+
+	select {
+	case payload := <-payloads:
+		require.Equal(t, fmt.Sprintf("%s %d", arg.TokenID, arg.ExpiresAt.Unix()), payload)
+	case <-ctx.Done():
+		t.Fatal("no notification received")
+	}
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/checkioname/simple-bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomRole(t *testing.T, permissions ...string) Role {
+	arg := CreateRoleParams{
+		Name:        "role_" + util.RandomOwner(),
+		Description: util.RandomOwner(),
+		Permissions: permissions,
+	}
+
+	role, err := testStore.CreateRole(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Name, role.Name)
+	require.ElementsMatch(t, permissions, role.Permissions)
+	require.NotZero(t, role.CreatedAt)
+	return role
+}
+
+func TestUserRoles(t *testing.T) {
+	ctx := context.Background()
+	user := createRandomUser(t)
+	editor := createRandomRole(t, "docs:read", "docs:write")
+	viewer := createRandomRole(t, "docs:read")
+
+	roles, err := testStore.ListUserRoles(ctx, user.Username)
+	require.NoError(t, err)
+	require.Empty(t, roles)
+
+	for _, role := range []Role{editor, viewer, editor} {
+		err := testStore.AddUserRole(ctx, AddUserRoleParams{Username: user.Username, Role: role.Name})
+		require.NoError(t, err)
+	}
+
+	roles, err = testStore.ListUserRoles(ctx, user.Username)
+	require.NoError(t, err)
+	require.Len(t, roles, 2)
+
+	removed, err := testStore.RemoveUserRole(ctx, RemoveUserRoleParams{Username: user.Username, Role: editor.Name})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, removed)
+
+	roles, err = testStore.ListUserRoles(ctx, user.Username)
+	require.NoError(t, err)
+	require.Len(t, roles, 1)
+	require.Equal(t, viewer.Name, roles[0].Name)
+	require.Equal(t, []string{"docs:read"}, roles[0].Permissions)
+
+	// Papéis inexistentes violam a chave estrangeira
+	err = testStore.AddUserRole(ctx, AddUserRoleParams{Username: user.Username, Role: "missing_" + util.RandomOwner()})
+	require.Error(t, err)
+}
+
+func TestSeededRoles(t *testing.T) {
+	admin, err := testStore.GetRole(context.Background(), "admin")
+	require.NoError(t, err)
+	require.Equal(t, []string{"*"}, admin.Permissions)
+
+	roles, err := testStore.ListRoles(context.Background())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(roles), 2)
+}
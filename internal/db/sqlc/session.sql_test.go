@@ -0,0 +1,203 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomSession(t *testing.T, username string, expiresAt time.Time) Session {
+	id := uuid.New()
+	arg := CreateSessionParams{
+		ID:           id,
+		Username:     username,
+		RefreshToken: uuid.NewString(),
+		UserAgent:    "go-test",
+		ClientIp:     "127.0.0.1",
+		ExpiresAt:    expiresAt,
+		FamilyID:     id,
+	}
+
+	session, err := testStore.CreateSession(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ID, session.ID)
+	require.Equal(t, arg.FamilyID, session.FamilyID)
+	require.False(t, session.IsBlocked)
+	require.False(t, session.IsRotated)
+	return session
+}
+
+func TestBlockSessions(t *testing.T) {
+	ctx := context.Background()
+	user := createRandomUser(t)
+	first := createRandomSession(t, user.Username, time.Now().Add(time.Hour))
+	second := createRandomSession(t, user.Username, time.Now().Add(time.Hour))
+	createRandomSession(t, user.Username, time.Now().Add(-time.Hour))
+
+	// Sessões expiradas não aparecem
+	sessions, err := testStore.ListSessions(ctx, user.Username)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+
+	require.NoError(t, testStore.BlockSession(ctx, first.ID))
+	session, err := testStore.GetSession(ctx, first.ID)
+	require.NoError(t, err)
+	require.True(t, session.IsBlocked)
+
+	// A sessão já bloqueada não conta de novo
+	blocked, err := testStore.BlockUserSessions(ctx, user.Username)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), blocked)
+
+	session, err = testStore.GetSession(ctx, second.ID)
+	require.NoError(t, err)
+	require.True(t, session.IsBlocked)
+}
+
+func TestRotateSessionTx(t *testing.T) {
+	ctx := context.Background()
+	user := createRandomUser(t)
+	first := createRandomSession(t, user.Username, time.Now().Add(time.Hour))
+
+	next := func() CreateSessionParams {
+		return CreateSessionParams{
+			ID:           uuid.New(),
+			Username:     user.Username,
+			RefreshToken: uuid.NewString(),
+			UserAgent:    first.UserAgent,
+			ClientIp:     first.ClientIp,
+			ExpiresAt:    first.ExpiresAt,
+			FamilyID:     first.FamilyID,
+		}
+	}
+
+	second, err := testStore.RotateSessionTx(ctx, RotateSessionTxParams{SessionID: first.ID, Next: next()})
+	require.NoError(t, err)
+	require.Equal(t, first.FamilyID, second.FamilyID)
+
+	// A sessão rotacionada não gera outra, e a falha não cria sessão
+	_, err = testStore.RotateSessionTx(ctx, RotateSessionTxParams{SessionID: first.ID, Next: next()})
+	require.ErrorIs(t, err, ErrSessionRotated)
+
+	family, err := testStore.ListSessionFamily(ctx, first.FamilyID)
+	require.NoError(t, err)
+	require.Len(t, family, 2)
+	require.True(t, family[0].IsRotated)
+	require.False(t, family[1].IsRotated)
+
+	blocked, err := testStore.BlockSessionFamily(ctx, first.FamilyID)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), blocked)
+}
+
+func TestListActiveSessions(t *testing.T) {
+	ctx := context.Background()
+	user := createRandomUser(t)
+	first := createRandomSession(t, user.Username, time.Now().Add(time.Hour))
+	for range 2 {
+		createRandomSession(t, user.Username, time.Now().Add(time.Hour))
+	}
+
+	// A sessão rotacionada some da lista e a nova mantém o horário do login
+	renewed, err := testStore.RotateSessionTx(ctx, RotateSessionTxParams{
+		SessionID: first.ID,
+		Next: CreateSessionParams{
+			ID:           uuid.New(),
+			Username:     user.Username,
+			RefreshToken: uuid.NewString(),
+			ExpiresAt:    first.ExpiresAt,
+			FamilyID:     first.FamilyID,
+		},
+	})
+	require.NoError(t, err)
+
+	total, err := testStore.CountActiveSessions(ctx, user.Username)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), total)
+
+	page, err := testStore.ListActiveSessions(ctx, ListActiveSessionsParams{Username: user.Username, Limit: 2, Offset: 0})
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	require.Equal(t, renewed.ID, page[0].ID)
+	require.WithinDuration(t, first.CreatedAt, page[0].SignedInAt, time.Millisecond)
+
+	page, err = testStore.ListActiveSessions(ctx, ListActiveSessionsParams{Username: user.Username, Limit: 2, Offset: 2})
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+}
+
+func TestDeleteExpiredSessions(t *testing.T) {
+	ctx := context.Background()
+	user := createRandomUser(t)
+	active := createRandomSession(t, user.Username, time.Now().Add(time.Hour))
+	recent := createRandomSession(t, user.Username, time.Now().Add(-time.Minute))
+	old := createRandomSession(t, user.Username, time.Now().Add(-48*time.Hour))
+
+	err := testStore.WithAdvisoryLock(ctx, 42, func(q *Queries) error {
+		// A mesma chave não pode ser obtida por outra transação
+		err := testStore.WithAdvisoryLock(ctx, 42, func(*Queries) error { return nil })
+		require.ErrorIs(t, err, ErrLockNotAcquired)
+
+		_, err = q.DeleteExpiredSessions(ctx, DeleteExpiredSessionsParams{
+			GracePeriodUs: (24 * time.Hour).Microseconds(),
+			BatchSize:     1000,
+		})
+		return err
+	})
+	require.NoError(t, err)
+
+	// Só a sessão expirada há mais que a carência é apagada
+	_, err = testStore.GetSession(ctx, old.ID)
+	require.Error(t, err)
+	for _, id := range []uuid.UUID{active.ID, recent.ID} {
+		_, err = testStore.GetSession(ctx, id)
+		require.NoError(t, err)
+	}
+}
+
+func TestCreateSessionTxLimit(t *testing.T) {
+	ctx := context.Background()
+	user := createRandomUser(t)
+	params := func() CreateSessionParams {
+		id := uuid.New()
+		return CreateSessionParams{
+			ID:           id,
+			Username:     user.Username,
+			RefreshToken: uuid.NewString(),
+			ExpiresAt:    time.Now().Add(time.Hour),
+			FamilyID:     id,
+		}
+	}
+
+	var first Session
+	for i := range 2 {
+		result, err := testStore.CreateSessionTx(ctx, CreateSessionTxParams{Session: params(), MaxSessions: 2})
+		require.NoError(t, err)
+		require.Empty(t, result.Evicted)
+		if i == 0 {
+			first = result.Session
+		}
+	}
+
+	_, err := testStore.CreateSessionTx(ctx, CreateSessionTxParams{Session: params(), MaxSessions: 2})
+	require.ErrorIs(t, err, ErrSessionLimitReached)
+
+	// Com evict_oldest, a sessão mais antiga dá lugar à nova
+	result, err := testStore.CreateSessionTx(ctx, CreateSessionTxParams{Session: params(), MaxSessions: 2, EvictOldest: true})
+	require.NoError(t, err)
+	require.Len(t, result.Evicted, 1)
+	require.Equal(t, first.ID, result.Evicted[0].ID)
+
+	total, err := testStore.CountActiveSessions(ctx, user.Username)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), total)
+
+	// users.max_sessions = 0 libera o usuário do limite padrão
+	_, err = testQueries.db.Exec(ctx, "UPDATE users SET max_sessions = 0 WHERE username = $1", user.Username)
+	require.NoError(t, err)
+	_, err = testStore.CreateSessionTx(ctx, CreateSessionTxParams{Session: params(), MaxSessions: 2})
+	require.NoError(t, err)
+}
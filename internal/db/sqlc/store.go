@@ -2,15 +2,39 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Store interface {
 	Querier
+	// Listen assina channel (LISTEN/NOTIFY) e chama notify com o payload de
+	// cada notificação. Bloqueia até ctx ser cancelado ou a conexão falhar.
+	Listen(ctx context.Context, channel string, notify func(payload string)) error
+	RotateSessionTx(ctx context.Context, arg RotateSessionTxParams) (Session, error)
+	WithAdvisoryLock(ctx context.Context, key int64, fn func(*Queries) error) error
+	CreateSessionTx(ctx context.Context, arg CreateSessionTxParams) (CreateSessionTxResult, error)
+	EnableTOTPTx(ctx context.Context, arg EnableTOTPTxParams) error
+	DisableTOTPTx(ctx context.Context, username string) (bool, error)
 }
 
+var (
+	// ErrSessionRotated indica que a sessão já foi rotacionada (ou bloqueada)
+	// e não pode gerar outra
+	ErrSessionRotated = errors.New("session already rotated")
+	// ErrLockNotAcquired indica que outra conexão detém o advisory lock
+	ErrLockNotAcquired = errors.New("advisory lock held by another session")
+	// ErrSessionLimitReached indica que o usuário já tem o máximo de sessões
+	// ativas e a política é recusar o login
+	ErrSessionLimitReached = errors.New("maximum number of active sessions reached")
+	// ErrTOTPNotPending indica que não há cadastro TOTP aguardando confirmação
+	ErrTOTPNotPending = errors.New("no pending TOTP enrollment")
+)
+
 type SQLStore struct {
 	connPool *pgxpool.Pool
 	*Queries
@@ -23,6 +47,179 @@ func NewStore(connPool *pgxpool.Pool) Store {
 	}
 }
 
+func (s *SQLStore) Listen(ctx context.Context, channel string, notify func(payload string)) error {
+	pooled, err := s.connPool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("Listen: %w", err)
+	}
+	// A conexão fica presa ao LISTEN: sai do pool e é fechada no fim
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return fmt.Errorf("Listen: %w", err)
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("Listen: %w", err)
+		}
+		notify(notification.Payload)
+	}
+}
+
+type RotateSessionTxParams struct {
+	// SessionID é a sessão do refresh token apresentado
+	SessionID uuid.UUID
+	// Next é a sessão do novo refresh token, na mesma família
+	Next CreateSessionParams
+}
+
+// RotateSessionTx marca a sessão como rotacionada e cria a próxima da família
+// na mesma transação. Só uma rotação por sessão vence: as demais recebem
+// ErrSessionRotated.
+func (s *SQLStore) RotateSessionTx(ctx context.Context, arg RotateSessionTxParams) (Session, error) {
+	var next Session
+	err := s.execTx(ctx, func(q *Queries) error {
+		rotated, err := q.RotateSession(ctx, arg.SessionID)
+		if err != nil {
+			return err
+		}
+		if rotated == 0 {
+			return ErrSessionRotated
+		}
+
+		next, err = q.CreateSession(ctx, arg.Next)
+		return err
+	})
+	return next, err
+}
+
+// WithAdvisoryLock roda fn numa transação que detém o advisory lock key. Se
+// outra instância já o tiver, não espera: retorna ErrLockNotAcquired.
+func (s *SQLStore) WithAdvisoryLock(ctx context.Context, key int64, fn func(*Queries) error) error {
+	return s.execTx(ctx, func(q *Queries) error {
+		locked, err := q.TryAdvisoryXactLock(ctx, key)
+		if err != nil {
+			return err
+		}
+		if !locked {
+			return ErrLockNotAcquired
+		}
+		return fn(q)
+	})
+}
+
+type CreateSessionTxParams struct {
+	Session CreateSessionParams
+	// MaxSessions é o limite padrão de sessões ativas; users.max_sessions,
+	// quando preenchido, tem precedência. 0 significa sem limite
+	MaxSessions int
+	// EvictOldest encerra as sessões usadas há mais tempo para abrir espaço;
+	// sem ele, o login é recusado com ErrSessionLimitReached
+	EvictOldest bool
+}
+
+type CreateSessionTxResult struct {
+	Session Session
+	// Evicted são as sessões bloqueadas para respeitar o limite. Os tokens
+	// delas ainda precisam ser revogados
+	Evicted []Session
+}
+
+// CreateSessionTx cria a sessão de um login respeitando o limite de sessões
+// ativas do usuário. A linha do usuário fica travada durante a transação,
+// então logins simultâneos não ultrapassam o limite.
+func (s *SQLStore) CreateSessionTx(ctx context.Context, arg CreateSessionTxParams) (CreateSessionTxResult, error) {
+	var result CreateSessionTxResult
+	err := s.execTx(ctx, func(q *Queries) error {
+		override, err := q.GetUserMaxSessionsForUpdate(ctx, arg.Session.Username)
+		if err != nil {
+			return err
+		}
+		limit := arg.MaxSessions
+		if override.Valid {
+			limit = int(override.Int32)
+		}
+
+		if limit > 0 {
+			active, err := q.CountActiveSessions(ctx, arg.Session.Username)
+			if err != nil {
+				return err
+			}
+			if excess := active - int64(limit) + 1; excess > 0 {
+				if !arg.EvictOldest {
+					return ErrSessionLimitReached
+				}
+				result.Evicted, err = q.ListOldestActiveSessions(ctx, ListOldestActiveSessionsParams{
+					Username: arg.Session.Username,
+					Limit:    int32(excess),
+				})
+				if err != nil {
+					return err
+				}
+				for _, evicted := range result.Evicted {
+					if _, err := q.BlockSessionFamily(ctx, evicted.FamilyID); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		result.Session, err = q.CreateSession(ctx, arg.Session)
+		return err
+	})
+	return result, err
+}
+
+type EnableTOTPTxParams struct {
+	Username string
+	// Step é o passo do código que confirmou o cadastro; não vale de novo
+	Step int64
+	// CodeHashes são os hashes dos novos códigos de recuperação, que
+	// substituem os anteriores
+	CodeHashes []string
+}
+
+// EnableTOTPTx ativa o TOTP pendente do usuário e troca seus códigos de
+// recuperação na mesma transação. Sem cadastro pendente, retorna
+// ErrTOTPNotPending.
+func (s *SQLStore) EnableTOTPTx(ctx context.Context, arg EnableTOTPTxParams) error {
+	return s.execTx(ctx, func(q *Queries) error {
+		enabled, err := q.EnableTOTP(ctx, EnableTOTPParams{Step: arg.Step, Username: arg.Username})
+		if err != nil {
+			return err
+		}
+		if enabled == 0 {
+			return ErrTOTPNotPending
+		}
+
+		if err := q.DeleteRecoveryCodes(ctx, arg.Username); err != nil {
+			return err
+		}
+		_, err = q.CreateRecoveryCodes(ctx, CreateRecoveryCodesParams{
+			Username:   arg.Username,
+			CodeHashes: arg.CodeHashes,
+		})
+		return err
+	})
+}
+
+// DisableTOTPTx apaga o TOTP e os códigos de recuperação do usuário. Retorna
+// false se ele não tinha TOTP.
+func (s *SQLStore) DisableTOTPTx(ctx context.Context, username string) (bool, error) {
+	var deleted int64
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+		if deleted, err = q.DeleteTOTP(ctx, username); err != nil {
+			return err
+		}
+		return q.DeleteRecoveryCodes(ctx, username)
+	})
+	return deleted > 0, err
+}
+
 func (s *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
 	tx, err := s.connPool.Begin(ctx)
 	if err != nil {
@@ -35,7 +232,7 @@ func (s *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
 		if rbErr := tx.Rollback(ctx); rbErr != nil {
 			return fmt.Errorf("execTx: %v, rb err: %v", err, rbErr)
 		}
-		return fmt.Errorf("execTx: %v", err)
+		return fmt.Errorf("execTx: %w", err)
 	}
 
 	err = tx.Commit(ctx)
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTOTPEnrollment(t *testing.T) {
+	ctx := context.Background()
+	user := createRandomUser(t)
+
+	pending, err := testStore.CreateTOTP(ctx, CreateTOTPParams{Username: user.Username, Secret: "FIRST"})
+	require.NoError(t, err)
+	require.False(t, pending.IsEnabled)
+
+	// Um cadastro pendente pode ser refeito
+	pending, err = testStore.CreateTOTP(ctx, CreateTOTPParams{Username: user.Username, Secret: "SECOND"})
+	require.NoError(t, err)
+	require.Equal(t, "SECOND", pending.Secret)
+
+	arg := EnableTOTPTxParams{Username: user.Username, Step: 100, CodeHashes: []string{"hash-a", "hash-b"}}
+	require.NoError(t, testStore.EnableTOTPTx(ctx, arg))
+	require.ErrorIs(t, testStore.EnableTOTPTx(ctx, arg), ErrTOTPNotPending)
+
+	totp, err := testStore.GetTOTP(ctx, user.Username)
+	require.NoError(t, err)
+	require.True(t, totp.IsEnabled)
+	require.Equal(t, int64(100), totp.LastUsedStep)
+
+	// Ativo, o segredo não é mais trocado
+	_, err = testStore.CreateTOTP(ctx, CreateTOTPParams{Username: user.Username, Secret: "THIRD"})
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	// Cada passo vale uma vez, e passos anteriores não valem mais
+	for _, tt := range []struct{ step, used int64 }{{100, 0}, {101, 1}, {101, 0}, {99, 0}} {
+		used, err := testStore.UseTOTPStep(ctx, UseTOTPStepParams{Step: tt.step, Username: user.Username})
+		require.NoError(t, err)
+		require.Equal(t, tt.used, used, "step %d", tt.step)
+	}
+
+	// Cada código de recuperação vale uma vez
+	codeArg := UseRecoveryCodeParams{Username: user.Username, CodeHash: "hash-a"}
+	used, err := testStore.UseRecoveryCode(ctx, codeArg)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), used)
+	used, err = testStore.UseRecoveryCode(ctx, codeArg)
+	require.NoError(t, err)
+	require.Zero(t, used)
+
+	disabled, err := testStore.DisableTOTPTx(ctx, user.Username)
+	require.NoError(t, err)
+	require.True(t, disabled)
+
+	_, err = testStore.GetTOTP(ctx, user.Username)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+	used, err = testStore.UseRecoveryCode(ctx, UseRecoveryCodeParams{Username: user.Username, CodeHash: "hash-b"})
+	require.NoError(t, err)
+	require.Zero(t, used)
+}
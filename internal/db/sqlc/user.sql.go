@@ -20,7 +20,7 @@ INSERT INTO users (
     is_whitelisted
 ) VALUES (
              $1, $2, $3, $4, $5
-         ) RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_whitelisted
+         ) RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_whitelisted, max_sessions
 `
 
 type CreateUserParams struct {
@@ -48,12 +48,13 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
 		&i.IsWhitelisted,
+		&i.MaxSessions,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT username, hashed_password, full_name, email, password_changed_at, created_at, is_whitelisted FROM users
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, is_whitelisted, max_sessions FROM users
 WHERE username = $1 LIMIT 1
 `
 
@@ -68,10 +69,26 @@ func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
 		&i.IsWhitelisted,
+		&i.MaxSessions,
 	)
 	return i, err
 }
 
+const getUserMaxSessionsForUpdate = `-- name: GetUserMaxSessionsForUpdate :one
+SELECT max_sessions FROM users
+WHERE username = $1 LIMIT 1
+FOR UPDATE
+`
+
+// Trava a linha do usuário: logins simultâneos do mesmo usuário passam um
+// por vez pela contagem de sessões
+func (q *Queries) GetUserMaxSessionsForUpdate(ctx context.Context, username string) (pgtype.Int4, error) {
+	row := q.db.QueryRow(ctx, getUserMaxSessionsForUpdate, username)
+	var max_sessions pgtype.Int4
+	err := row.Scan(&max_sessions)
+	return max_sessions, err
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users
 SET
@@ -81,7 +98,7 @@ SET
     email = COALESCE($4, email)
 WHERE
     username = $5
-    RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_whitelisted
+    RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_whitelisted, max_sessions
 `
 
 type UpdateUserParams struct {
@@ -109,6 +126,7 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
 		&i.IsWhitelisted,
+		&i.MaxSessions,
 	)
 	return i, err
 }
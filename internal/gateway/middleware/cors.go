@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/go-chi/cors"
+)
+
+// CORSConfig define a política CORS do gateway ou de uma rota. Origens aceitam
+// um curinga cada, como https://*.example.com; "*" libera qualquer origem.
+type CORSConfig struct {
+	AllowedOrigins   []string      `yaml:"allowed_origins" json:"allowed_origins"`
+	AllowedMethods   []string      `yaml:"allowed_methods" json:"allowed_methods"`
+	AllowedHeaders   []string      `yaml:"allowed_headers" json:"allowed_headers"`
+	ExposedHeaders   []string      `yaml:"exposed_headers" json:"exposed_headers"`
+	AllowCredentials *bool         `yaml:"allow_credentials" json:"allow_credentials"`
+	MaxAge           time.Duration `yaml:"max_age" json:"max_age"`
+}
+
+// Override retorna a política com os campos definidos em route substituindo
+// os desta. route pode ser nil.
+func (c CORSConfig) Override(route *CORSConfig) CORSConfig {
+	if route == nil {
+		return c
+	}
+	if len(route.AllowedOrigins) > 0 {
+		c.AllowedOrigins = route.AllowedOrigins
+	}
+	if len(route.AllowedMethods) > 0 {
+		c.AllowedMethods = route.AllowedMethods
+	}
+	if len(route.AllowedHeaders) > 0 {
+		c.AllowedHeaders = route.AllowedHeaders
+	}
+	if len(route.ExposedHeaders) > 0 {
+		c.ExposedHeaders = route.ExposedHeaders
+	}
+	if route.AllowCredentials != nil {
+		c.AllowCredentials = route.AllowCredentials
+	}
+	if route.MaxAge > 0 {
+		c.MaxAge = route.MaxAge
+	}
+	return c
+}
+
+// CORS cria o middleware CORS. Requisições de preflight são respondidas aqui
+// mesmo, antes de autenticação e rate limiting.
+func CORS(cfg CORSConfig) func(http.Handler) http.Handler {
+	credentials := cfg.AllowCredentials != nil && *cfg.AllowCredentials
+	opts := cors.Options{
+		AllowedOrigins:   cfg.AllowedOrigins,
+		AllowedMethods:   cfg.AllowedMethods,
+		AllowedHeaders:   cfg.AllowedHeaders,
+		ExposedHeaders:   cfg.ExposedHeaders,
+		AllowCredentials: credentials,
+		MaxAge:           int(cfg.MaxAge.Seconds()),
+	}
+
+	// Navegadores recusam "Access-Control-Allow-Origin: *" em requisições com
+	// credenciais; nesse caso a origem da requisição é devolvida no cabeçalho
+	if credentials && slices.Contains(cfg.AllowedOrigins, "*") {
+		opts.AllowedOrigins = nil
+		opts.AllowOriginFunc = func(*http.Request, string) bool { return true }
+	}
+
+	return cors.Handler(opts)
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCORSReflectsOriginWithCredentials(t *testing.T) {
+	allowCredentials := true
+	testCases := []struct {
+		name   string
+		cfg    CORSConfig
+		origin string
+		want   string
+	}{
+		{"wildcard without credentials", CORSConfig{AllowedOrigins: []string{"*"}}, "https://a.io", "*"},
+		{"wildcard with credentials", CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: &allowCredentials}, "https://a.io", "https://a.io"},
+		{"subdomain", CORSConfig{AllowedOrigins: []string{"https://*.example.com"}}, "https://app.example.com", "https://app.example.com"},
+		{"not allowed", CORSConfig{AllowedOrigins: []string{"https://*.example.com"}}, "https://example.org", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Origin", tc.origin)
+			w := httptest.NewRecorder()
+
+			CORS(tc.cfg)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).ServeHTTP(w, r)
+			require.Equal(t, tc.want, w.Header().Get("Access-Control-Allow-Origin"))
+		})
+	}
+}
+
+func TestCORSOverride(t *testing.T) {
+	allowCredentials := false
+	base := CORSConfig{AllowedOrigins: []string{"http://localhost:3000"}, AllowedMethods: []string{"GET"}}
+
+	cfg := base.Override(&CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: &allowCredentials})
+	require.Equal(t, []string{"*"}, cfg.AllowedOrigins)
+	require.Equal(t, []string{"GET"}, cfg.AllowedMethods)
+	require.False(t, *cfg.AllowCredentials)
+
+	require.Equal(t, base, base.Override(nil))
+}
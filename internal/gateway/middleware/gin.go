@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Adapt converte um middleware net/http em um middleware do gin. Se o
+// middleware não chamar o próximo handler (por exemplo, ao responder 403), a
+// cadeia do gin é interrompida.
+func Adapt(mw func(http.Handler) http.Handler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		called := false
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+			// O middleware pode ter trocado a requisição (ex.: contexto com o payload)
+			c.Request = r
+			c.Next()
+		})
+
+		mw(next).ServeHTTP(c.Writer, c.Request)
+		if !called {
+			c.Abort()
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/time/rate"
+
+	db "api--sigacore-gateway/internal/db/sqlc"
+)
+
+// Limit é a taxa sustentada e a rajada permitidas para uma chave.
+type Limit struct {
+	Rate  rate.Limit
+	Burst int
+}
+
+// NewLimit cria um limite com rajada equivalente a um segundo de tráfego.
+func NewLimit(r rate.Limit) Limit {
+	return Limit{Rate: r, Burst: max(1, int(math.Ceil(float64(r))))}
+}
+
+// emissionInterval é o intervalo entre duas requisições na taxa sustentada.
+func (l Limit) emissionInterval() time.Duration {
+	return time.Duration(float64(time.Second) / float64(l.Rate))
+}
+
+// burstOffset é quanto o TAT pode se adiantar em relação ao relógio.
+func (l Limit) burstOffset() time.Duration {
+	return l.emissionInterval() * time.Duration(l.Burst)
+}
+
+// LimitResult é a decisão do store para uma requisição.
+type LimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// ResetAfter é o tempo até o bucket da chave estar cheio de novo
+	ResetAfter time.Duration
+	// RetryAfter só é preenchido quando a requisição é negada
+	RetryAfter time.Duration
+}
+
+// LimiterStore guarda o estado dos limites por chave. As implementações usam
+// GCRA (generic cell rate algorithm): o único estado de cada chave é o TAT,
+// o instante em que o bucket estaria cheio de novo.
+type LimiterStore interface {
+	Take(ctx context.Context, key string, limit Limit) (LimitResult, error)
+}
+
+// gcraResult monta o resultado a partir do TAT da chave: o novo TAT se a
+// requisição foi aceita, ou o TAT atual se foi negada.
+func gcraResult(limit Limit, now, tat time.Time, allowed bool) LimitResult {
+	emission := limit.emissionInterval()
+	ahead := max(0, tat.Sub(now))
+
+	res := LimitResult{
+		Allowed:    allowed,
+		Limit:      limit.Burst,
+		Remaining:  max(0, int((limit.burstOffset()-ahead)/emission)),
+		ResetAfter: ahead,
+	}
+	if !allowed {
+		res.RetryAfter = max(0, ahead+emission-limit.burstOffset())
+	}
+	return res
+}
+
+// MemoryLimiterStore mantém os limites no processo. Com mais de uma réplica do
+// gateway, cada uma aplica o limite inteiro; use PostgresLimiterStore nesse caso.
+type MemoryLimiterStore struct {
+	mutex         sync.Mutex
+	tats          map[string]time.Time
+	cleanInterval time.Duration
+}
+
+func NewMemoryLimiterStore() *MemoryLimiterStore {
+	return &MemoryLimiterStore{
+		tats:          make(map[string]time.Time),
+		cleanInterval: time.Minute * 10,
+	}
+}
+
+func (s *MemoryLimiterStore) Take(_ context.Context, key string, limit Limit) (LimitResult, error) {
+	now := time.Now()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	tat := s.tats[key]
+	if tat.Before(now) {
+		tat = now
+	}
+
+	newTAT := tat.Add(limit.emissionInterval())
+	if newTAT.Sub(now) > limit.burstOffset() {
+		return gcraResult(limit, now, tat, false), nil
+	}
+
+	s.tats[key] = newTAT
+	return gcraResult(limit, now, newTAT, true), nil
+}
+
+// Start remove, a cada cleanInterval, as chaves cujo bucket já está cheio.
+// Roda até ctx ser cancelado.
+func (s *MemoryLimiterStore) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.cleanInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.evict(now)
+			}
+		}
+	}()
+}
+
+func (s *MemoryLimiterStore) evict(now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for key, tat := range s.tats {
+		if tat.Before(now) {
+			delete(s.tats, key)
+		}
+	}
+}
+
+// PostgresLimiterStore compartilha os limites entre as réplicas do gateway.
+// A decisão é tomada numa única instrução SQL e usa o relógio do banco, então
+// réplicas com relógios diferentes aplicam o mesmo limite.
+type PostgresLimiterStore struct {
+	store         db.Store
+	cleanInterval time.Duration
+}
+
+func NewPostgresLimiterStore(store db.Store) *PostgresLimiterStore {
+	return &PostgresLimiterStore{
+		store:         store,
+		cleanInterval: time.Minute,
+	}
+}
+
+func (s *PostgresLimiterStore) Take(ctx context.Context, key string, limit Limit) (LimitResult, error) {
+	row, err := s.store.ConsumeRateLimit(ctx, db.ConsumeRateLimitParams{
+		Key:                key,
+		EmissionIntervalUs: limit.emissionInterval().Microseconds(),
+		BurstOffsetUs:      limit.burstOffset().Microseconds(),
+	})
+	if err == nil {
+		return gcraResult(limit, row.Now, row.Tat, true), nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return LimitResult{}, err
+	}
+
+	// Requisição negada: o TAT atual só é usado para calcular o Retry-After
+	state, err := s.store.GetRateLimit(ctx, key)
+	if err != nil {
+		return LimitResult{}, err
+	}
+	return gcraResult(limit, state.Now, state.Tat, false), nil
+}
+
+// Start apaga, a cada cleanInterval, as chaves cujo bucket já está cheio.
+// Roda até ctx ser cancelado.
+func (s *PostgresLimiterStore) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.cleanInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.store.DeleteStaleRateLimits(ctx); err != nil && ctx.Err() == nil {
+					log.Printf("rate limiter: cannot delete stale keys: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// StoreRateLimiter cria um middleware que limita todas as requisições sob a
+// mesma chave usando store. Se o store falhar, a requisição segue: uma
+// indisponibilidade do banco não deve derrubar o gateway.
+func StoreRateLimiter(store LimiterStore, key string, limit Limit) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			res, err := store.Take(r.Context(), key, limit)
+			if err != nil {
+				log.Printf("rate limiter: %s: %v", key, err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			setRateLimitHeaders(w.Header(), res)
+			if !res.Allowed {
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// setRateLimitHeaders escreve os cabeçalhos RateLimit-* (draft IETF
+// RateLimit header fields) e, se a requisição foi negada, o Retry-After.
+func setRateLimitHeaders(h http.Header, res LimitResult) {
+	h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.ResetAfter)))
+	if !res.Allowed {
+		h.Set("Retry-After", strconv.Itoa(max(1, ceilSeconds(res.RetryAfter))))
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLimiterStore(t *testing.T) {
+	store := NewMemoryLimiterStore()
+	limit := Limit{Rate: 1, Burst: 3}
+
+	for i := 2; i >= 0; i-- {
+		res, err := store.Take(context.Background(), "ip:10.0.0.1", limit)
+		require.NoError(t, err)
+		require.True(t, res.Allowed)
+		require.Equal(t, 3, res.Limit)
+		require.Equal(t, i, res.Remaining)
+	}
+
+	res, err := store.Take(context.Background(), "ip:10.0.0.1", limit)
+	require.NoError(t, err)
+	require.False(t, res.Allowed)
+	require.Zero(t, res.Remaining)
+	require.InDelta(t, time.Second, res.RetryAfter, float64(50*time.Millisecond))
+
+	// Chaves diferentes não dividem o limite
+	res, err = store.Take(context.Background(), "ip:10.0.0.2", limit)
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+
+	store.evict(time.Now().Add(time.Hour))
+	require.Empty(t, store.tats)
+}
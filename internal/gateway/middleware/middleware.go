@@ -2,36 +2,40 @@ package middleware
 
 import (
 	"api--sigacore-gateway/internal/token"
-	"context"
+	"api--sigacore-gateway/internal/util"
 	"fmt"
 	"log"
-	"net"
 	"net/http"
+	"net/netip"
 	"strings"
-
-	"golang.org/x/time/rate"
 )
 
-type contextKey string
-
-const authPayloadKey = contextKey("authorization_payload")
+// IPWhitelist só deixa passar clientes cujo IP pertence a uma das redes
+// permitidas. O IP do cliente é resolvido por ClientIP.
+func IPWhitelist(allowed, trustedProxies []netip.Prefix) func(http.Handler) http.Handler {
+	return ipFilter(trustedProxies, func(ip netip.Addr) bool {
+		return util.NetworksContain(allowed, ip)
+	})
+}
 
-// IPWhitelist verifica se o IP da requisição está na lista de permissão.
-func IPWhitelist(allowedIPs ...string) func(http.Handler) http.Handler {
-	whitelist := make(map[string]struct{})
-	for _, ip := range allowedIPs {
-		whitelist[ip] = struct{}{}
-	}
+// IPDenylist bloqueia clientes cujo IP pertence a uma das redes negadas.
+func IPDenylist(denied, trustedProxies []netip.Prefix) func(http.Handler) http.Handler {
+	return ipFilter(trustedProxies, func(ip netip.Addr) bool {
+		return !util.NetworksContain(denied, ip)
+	})
+}
 
+func ipFilter(trustedProxies []netip.Prefix, allow func(ip netip.Addr) bool) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			ip := r.Header.Get("X-Forwarded-For")
-			if ip == "" {
-				ip, _, _ = net.SplitHostPort(r.RemoteAddr)
+			ip, err := ClientIP(r, trustedProxies)
+			if err != nil {
+				log.Printf("Blocked: remote %s, X-Forwarded-For %q: %v", r.RemoteAddr, r.Header.Values("X-Forwarded-For"), err)
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
 			}
-
-			if _, found := whitelist[ip]; !found {
-				log.Printf("Blocked: IP %s is not in whitelist", ip)
+			if !allow(ip) {
+				log.Printf("Blocked: IP %s (remote %s, X-Forwarded-For %q)", ip, r.RemoteAddr, r.Header.Values("X-Forwarded-For"))
 				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
 				return
 			}
@@ -40,19 +44,37 @@ func IPWhitelist(allowedIPs ...string) func(http.Handler) http.Handler {
 	}
 }
 
-// RateLimiter cria um middleware de limite de requisições.
-func RateLimiter(r rate.Limit, b int) func(http.Handler) http.Handler {
-	limiter := rate.NewLimiter(r, b)
+// ClientIP resolve o IP real do cliente. X-Forwarded-For só é considerado se
+// a conexão vier de um proxy confiável; nesse caso, o cliente é o hop mais à
+// direita que não é um proxy confiável, já que os hops à esquerda dele podem
+// ter sido forjados. Retorna erro se algum hop relevante for inválido.
+func ClientIP(r *http.Request, trustedProxies []netip.Prefix) (netip.Addr, error) {
+	remote, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("ClientIP: invalid remote address: %w", err)
+	}
+	ip := remote.Addr().Unmap()
+	if !util.NetworksContain(trustedProxies, ip) {
+		return ip, nil
+	}
 
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if !limiter.Allow() {
-				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
-				return
-			}
-			next.ServeHTTP(w, r)
-		})
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
 	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			return netip.Addr{}, fmt.Errorf("ClientIP: invalid X-Forwarded-For hop: %w", err)
+		}
+		ip = hop.Unmap()
+		if !util.NetworksContain(trustedProxies, ip) {
+			return ip, nil
+		}
+	}
+
+	// Todos os hops são proxies confiáveis: o mais à esquerda é o cliente
+	return ip, nil
 }
 
 // AuthMiddleware verifica o token de autenticação Paseto para o gateway.
@@ -85,8 +107,7 @@ func AuthMiddleware(tokenMaker token.Maker) func(http.Handler) http.Handler {
 			}
 
 			// Adiciona o payload ao contexto da requisição para uso futuro
-			ctx := context.WithValue(r.Context(), authPayloadKey, payload)
-			next.ServeHTTP(w, r.WithContext(ctx))
+			next.ServeHTTP(w, r.WithContext(token.NewContext(r.Context(), payload)))
 		})
 	}
 }
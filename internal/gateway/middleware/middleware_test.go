@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"api--sigacore-gateway/internal/util"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted, err := util.ParseNetworks([]string{"10.0.0.0/8", "fd00::/8"})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		want       string
+		ok         bool
+	}{
+		{"untrusted remote ignores header", "203.0.113.7:5000", []string{"1.2.3.4"}, "203.0.113.7", true},
+		{"trusted remote uses header", "10.0.0.1:5000", []string{"198.51.100.2"}, "198.51.100.2", true},
+		{"rightmost untrusted hop", "10.0.0.1:5000", []string{"6.6.6.6, 198.51.100.2, 10.0.0.9"}, "198.51.100.2", true},
+		{"multiple headers", "10.0.0.1:5000", []string{"6.6.6.6", "198.51.100.2"}, "198.51.100.2", true},
+		{"all hops trusted", "10.0.0.1:5000", []string{"10.1.1.1, 10.2.2.2"}, "10.1.1.1", true},
+		{"ipv6", "[fd00::1]:5000", []string{"2001:db8::1"}, "2001:db8::1", true},
+		{"ipv4 mapped", "[::ffff:203.0.113.7]:5000", nil, "203.0.113.7", true},
+		{"invalid hop", "10.0.0.1:5000", []string{"not-an-ip"}, "", false},
+		{"invalid remote", "pipe", nil, "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tc.remoteAddr
+			for _, value := range tc.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+
+			ip, err := ClientIP(r, trusted)
+			if !tc.ok {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, ip.String())
+		})
+	}
+}
+
+func TestIPFilters(t *testing.T) {
+	networks, err := util.ParseNetworks([]string{"192.168.0.0/16", "2001:db8::/32"})
+	require.NoError(t, err)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	status := func(mw func(http.Handler) http.Handler, remoteAddr string) int {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = remoteAddr
+		r.Header.Set("X-Forwarded-For", "192.168.1.1")
+		w := httptest.NewRecorder()
+		mw(next).ServeHTTP(w, r)
+		return w.Code
+	}
+
+	allow := IPWhitelist(networks, nil)
+	require.Equal(t, http.StatusOK, status(allow, "192.168.10.20:1234"))
+	require.Equal(t, http.StatusOK, status(allow, "[2001:db8::5]:1234"))
+	// Sem proxies confiáveis, X-Forwarded-For forjado não libera o acesso
+	require.Equal(t, http.StatusForbidden, status(allow, "203.0.113.7:1234"))
+
+	deny := IPDenylist(networks, nil)
+	require.Equal(t, http.StatusForbidden, status(deny, "192.168.10.20:1234"))
+	require.Equal(t, http.StatusOK, status(deny, "203.0.113.7:1234"))
+
+	// Endereço inválido bloqueia nos dois filtros; o log traz o endereço
+	// recebido e o motivo, não um IP vazio
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	require.Equal(t, http.StatusForbidden, status(deny, "pipe"))
+	require.Equal(t, http.StatusForbidden, status(allow, "pipe"))
+	require.Contains(t, logs.String(), "remote pipe")
+	require.Contains(t, logs.String(), "invalid remote address")
+	require.NotContains(t, logs.String(), "invalid IP")
+}
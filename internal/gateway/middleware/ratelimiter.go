@@ -1,29 +1,56 @@
 package middleware
 
 import (
-	"sync"
-	"time"
+	"log"
+	"net/http"
 
+	"github.com/gin-gonic/gin"
 	"golang.org/x/time/rate"
+
+	sharedmw "api--sigacore-gateway/internal/shared/middleware"
 )
 
+// CustomRateLimiter limita requisições por cliente: por IP para tráfego
+// anônimo e pelo username do token PASETO para tráfego autenticado. O estado
+// fica em store, que pode ser compartilhado entre réplicas.
 type CustomRateLimiter struct {
-	ipLimiters    map[string]*rate.Limiter
-	userLimiters  map[string]*rate.Limiter
-	mutex         sync.RWMutex
-	ipLimit       rate.Limit
-	userLimit     rate.Limit
-	cleanInterval time.Duration
+	store     LimiterStore
+	ipLimit   Limit
+	userLimit Limit
 }
 
-func NewRateLimiter(ipLimit, userLimit rate.Limit) *CustomRateLimiter {
-	rl := &CustomRateLimiter{
-		ipLimiters:    make(map[string]*rate.Limiter),
-		userLimiters:  make(map[string]*rate.Limiter),
-		ipLimit:       ipLimit,
-		userLimit:     userLimit,
-		cleanInterval: time.Minute * 10,
+func NewRateLimiter(store LimiterStore, ipLimit, userLimit rate.Limit) *CustomRateLimiter {
+	return &CustomRateLimiter{
+		store:     store,
+		ipLimit:   NewLimit(ipLimit),
+		userLimit: NewLimit(userLimit),
 	}
+}
+
+// Middleware aplica o limite ao cliente da requisição. Para identificar o
+// usuário, deve vir depois do middleware de autenticação na cadeia da rota.
+// Se o store falhar, a requisição segue sem limite.
+func (rl *CustomRateLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, limit := "ip:"+c.ClientIP(), rl.ipLimit
+		if payload, ok := sharedmw.AuthPayload(c); ok {
+			key, limit = "user:"+payload.Username, rl.userLimit
+		}
 
-	return rl
+		res, err := rl.store.Take(c.Request.Context(), key, limit)
+		if err != nil {
+			log.Printf("rate limiter: %s: %v", key, err)
+			c.Next()
+			return
+		}
+
+		setRateLimitHeaders(c.Writer.Header(), res)
+		if !res.Allowed {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests,
+				gin.H{"error": http.StatusText(http.StatusTooManyRequests)})
+			return
+		}
+
+		c.Next()
+	}
 }
@@ -0,0 +1,175 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"api--sigacore-gateway/internal/token"
+)
+
+// failingLimiterStore simula o banco fora do ar.
+type failingLimiterStore struct{}
+
+func (failingLimiterStore) Take(context.Context, string, Limit) (LimitResult, error) {
+	return LimitResult{}, errors.New("connection refused")
+}
+
+// newRateLimitedEngine monta um engine gin com o rate limiter. O cabeçalho
+// X-Test-User faz o papel do middleware de autenticação, que vem antes na
+// cadeia da rota.
+func newRateLimitedEngine(t *testing.T, rl *CustomRateLimiter) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	require.NoError(t, engine.SetTrustedProxies(nil))
+	engine.GET("/", func(c *gin.Context) {
+		if username := c.GetHeader("X-Test-User"); username != "" {
+			c.Request = c.Request.WithContext(token.NewContext(c.Request.Context(), &token.Payload{Username: username}))
+		}
+	}, rl.Middleware(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return engine
+}
+
+func TestRateLimiterKeys(t *testing.T) {
+	type request struct {
+		ip, user      string
+		wantStatus    int
+		wantRemaining string
+	}
+
+	testCases := []struct {
+		name     string
+		requests []request
+	}{
+		{
+			name: "per ip",
+			requests: []request{
+				{ip: "192.0.2.1", wantStatus: http.StatusOK, wantRemaining: "0"},
+				{ip: "192.0.2.1", wantStatus: http.StatusTooManyRequests, wantRemaining: "0"},
+				{ip: "192.0.2.2", wantStatus: http.StatusOK, wantRemaining: "0"},
+			},
+		},
+		{
+			name: "per user across ips",
+			requests: []request{
+				{ip: "192.0.2.1", user: "alice", wantStatus: http.StatusOK, wantRemaining: "2"},
+				{ip: "192.0.2.2", user: "alice", wantStatus: http.StatusOK, wantRemaining: "1"},
+				{ip: "192.0.2.3", user: "alice", wantStatus: http.StatusOK, wantRemaining: "0"},
+				{ip: "192.0.2.4", user: "alice", wantStatus: http.StatusTooManyRequests, wantRemaining: "0"},
+				{ip: "192.0.2.1", user: "bob", wantStatus: http.StatusOK, wantRemaining: "2"},
+			},
+		},
+		{
+			name: "user does not spend the ip limit",
+			requests: []request{
+				{ip: "192.0.2.1", user: "alice", wantStatus: http.StatusOK, wantRemaining: "2"},
+				{ip: "192.0.2.1", wantStatus: http.StatusOK, wantRemaining: "0"},
+				{ip: "192.0.2.1", user: "alice", wantStatus: http.StatusOK, wantRemaining: "1"},
+				{ip: "192.0.2.1", wantStatus: http.StatusTooManyRequests, wantRemaining: "0"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Taxas baixas o bastante para o bucket não encher durante o teste
+			engine := newRateLimitedEngine(t, NewRateLimiter(NewMemoryLimiterStore(), 0.5, 3))
+
+			for i, req := range tc.requests {
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r.RemoteAddr = req.ip + ":5000"
+				if req.user != "" {
+					r.Header.Set("X-Test-User", req.user)
+				}
+				w := httptest.NewRecorder()
+				engine.ServeHTTP(w, r)
+
+				require.Equal(t, req.wantStatus, w.Code, "request %d", i)
+				require.Equal(t, req.wantRemaining, w.Header().Get("RateLimit-Remaining"), "request %d", i)
+			}
+		})
+	}
+}
+
+func TestRateLimiterHeaders(t *testing.T) {
+	engine := newRateLimitedEngine(t, NewRateLimiter(NewMemoryLimiterStore(), 0.5, 3))
+	send := func() *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = "192.0.2.1:5000"
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, r)
+		return w
+	}
+
+	// Uma requisição a cada 2s, rajada de 1
+	w := send()
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "1", w.Header().Get("RateLimit-Limit"))
+	require.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	require.Equal(t, "2", w.Header().Get("RateLimit-Reset"))
+	require.Empty(t, w.Header().Get("Retry-After"))
+
+	w = send()
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.JSONEq(t, `{"error":"Too Many Requests"}`, w.Body.String())
+	require.Equal(t, "1", w.Header().Get("RateLimit-Limit"))
+	require.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	require.Equal(t, "2", w.Header().Get("RateLimit-Reset"))
+	require.Equal(t, "2", w.Header().Get("Retry-After"))
+}
+
+func TestRateLimiterStoreFailure(t *testing.T) {
+	engine := newRateLimitedEngine(t, NewRateLimiter(failingLimiterStore{}, 0.5, 3))
+
+	// Com o store fora do ar, a requisição segue sem limite e sem cabeçalhos
+	for range 3 {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Empty(t, w.Header().Get("RateLimit-Limit"))
+	}
+}
+
+func TestRateLimiterEviction(t *testing.T) {
+	store := NewMemoryLimiterStore()
+	engine := newRateLimitedEngine(t, NewRateLimiter(store, 0.5, 3))
+
+	for _, ip := range []string{"192.0.2.1", "192.0.2.2"} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = ip + ":5000"
+		engine.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Test-User", "alice")
+	engine.ServeHTTP(httptest.NewRecorder(), r)
+	require.Len(t, store.tats, 3)
+
+	// Um cliente que ficou parado até o bucket encher é removido; quem ainda
+	// tem o bucket em uso fica
+	store.mutex.Lock()
+	store.tats["ip:192.0.2.2"] = time.Now().Add(-time.Second)
+	store.mutex.Unlock()
+	store.evict(time.Now())
+	require.Len(t, store.tats, 2)
+	require.NotContains(t, store.tats, "ip:192.0.2.2")
+
+	store.evict(time.Now().Add(time.Hour))
+	require.Empty(t, store.tats)
+
+	// Depois da remoção, o cliente volta com o bucket cheio
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "192.0.2.1:5000"
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	sharedmw "api--sigacore-gateway/internal/shared/middleware"
+	"api--sigacore-gateway/internal/token"
+)
+
+// TokenExchange troca o token do usuário por um token interno de vida curta,
+// emitido por maker para o backend da rota. O token do usuário não sai do
+// gateway. Deve vir depois do middleware de autenticação na cadeia da rota.
+func TokenExchange(maker token.Maker, duration time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payload, ok := sharedmw.AuthPayload(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized,
+				gin.H{"error": http.StatusText(http.StatusUnauthorized)})
+			return
+		}
+
+		// O token interno nunca vive mais que o token do usuário e leva os
+		// mesmos papéis e escopos, para que o backend aplique o próprio RBAC
+		internalToken, _, err := maker.CreateToken(payload.Username,
+			min(duration, time.Until(payload.ExpiredAt)), token.WithSessionID(payload.SessionID),
+			token.WithRoles(payload.Roles, payload.Permissions), token.WithScopes(payload.Scopes...))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError,
+				gin.H{"error": http.StatusText(http.StatusInternalServerError)})
+			return
+		}
+
+		c.Request.Header.Set("Authorization", "Bearer "+internalToken)
+		c.Next()
+	}
+}
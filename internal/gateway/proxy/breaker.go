@@ -0,0 +1,269 @@
+package proxy
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// BreakerState é o estado de um circuit breaker.
+type BreakerState int
+
+const (
+	StateClosed BreakerState = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText permite expor o estado como texto no JSON de monitoramento.
+func (s BreakerState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerConfig define quando o circuito de um upstream abre. O breaker fica
+// desabilitado se nem ConsecutiveFailures nem ErrorRate forem configurados.
+type BreakerConfig struct {
+	// ConsecutiveFailures abre o circuito após N falhas seguidas
+	ConsecutiveFailures int `yaml:"consecutive_failures" json:"consecutive_failures"`
+	// ErrorRate abre o circuito quando a taxa de falhas na janela atinge o
+	// valor (0 a 1), desde que a janela tenha ao menos MinRequests requisições
+	ErrorRate   float64       `yaml:"error_rate" json:"error_rate"`
+	MinRequests int           `yaml:"min_requests" json:"min_requests"`
+	Window      time.Duration `yaml:"window" json:"window"`
+	// OpenTimeout é o tempo em aberto antes de deixar passar requisições de teste
+	OpenTimeout         time.Duration `yaml:"open_timeout" json:"open_timeout"`
+	HalfOpenMaxRequests int           `yaml:"half_open_max_requests" json:"half_open_max_requests"`
+}
+
+// Enabled indica se algum critério de abertura foi configurado.
+func (c BreakerConfig) Enabled() bool {
+	return c.ConsecutiveFailures > 0 || c.ErrorRate > 0
+}
+
+func (c BreakerConfig) withDefaults() BreakerConfig {
+	if c.MinRequests == 0 {
+		c.MinRequests = 20
+	}
+	if c.Window == 0 {
+		c.Window = 30 * time.Second
+	}
+	if c.OpenTimeout == 0 {
+		c.OpenTimeout = 30 * time.Second
+	}
+	if c.HalfOpenMaxRequests == 0 {
+		c.HalfOpenMaxRequests = 1
+	}
+	return c
+}
+
+// StateChangeFunc é chamada a cada transição de estado de um breaker. Ela roda
+// com o breaker travado, então deve ser rápida e não pode chamar o breaker.
+type StateChangeFunc func(name string, from, to BreakerState)
+
+// CircuitBreaker protege um upstream: depois de falhas demais, as requisições
+// são recusadas imediatamente até o fim de OpenTimeout, quando algumas
+// requisições de teste (half-open) decidem se o circuito fecha ou reabre.
+type CircuitBreaker struct {
+	name          string
+	cfg           BreakerConfig
+	onStateChange StateChangeFunc
+	now           func() time.Time
+
+	mu                  sync.Mutex
+	state               BreakerState
+	consecutiveFailures int
+	requests            int
+	failures            int
+	windowStart         time.Time
+	openedAt            time.Time
+	halfOpenInFlight    int
+	halfOpenSuccesses   int
+	transitions         uint64
+}
+
+// NewCircuitBreaker cria um breaker fechado. onStateChange pode ser nil.
+func NewCircuitBreaker(name string, cfg BreakerConfig, onStateChange StateChangeFunc) *CircuitBreaker {
+	return &CircuitBreaker{
+		name:          name,
+		cfg:           cfg.withDefaults(),
+		onStateChange: onStateChange,
+		now:           time.Now,
+		windowStart:   time.Now(),
+	}
+}
+
+// State retorna o estado atual, já considerando o fim do OpenTimeout.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh(b.now())
+	return b.state
+}
+
+// Transitions retorna quantas vezes o breaker mudou de estado.
+func (b *CircuitBreaker) Transitions() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.transitions
+}
+
+// Ready indica se o breaker aceitaria uma requisição agora, sem reservá-la.
+func (b *CircuitBreaker) Ready() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh(b.now())
+	switch b.state {
+	case StateOpen:
+		return false
+	case StateHalfOpen:
+		return b.halfOpenInFlight < b.cfg.HalfOpenMaxRequests
+	default:
+		return true
+	}
+}
+
+// Allow reserva a passagem de uma requisição. Toda chamada bem-sucedida deve
+// ser seguida de Success, Failure ou Release.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh(b.now())
+	switch b.state {
+	case StateOpen:
+		return ErrCircuitOpen
+	case StateHalfOpen:
+		if b.halfOpenInFlight >= b.cfg.HalfOpenMaxRequests {
+			return ErrCircuitOpen
+		}
+		b.halfOpenInFlight++
+	}
+	return nil
+}
+
+// Success registra uma resposta bem-sucedida do upstream.
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	switch b.state {
+	case StateHalfOpen:
+		b.releaseHalfOpen()
+		b.halfOpenSuccesses++
+		if b.halfOpenSuccesses >= b.cfg.HalfOpenMaxRequests {
+			b.setState(StateClosed, now)
+		}
+	case StateClosed:
+		b.rollWindow(now)
+		b.requests++
+		b.consecutiveFailures = 0
+	}
+}
+
+// Failure registra uma falha do upstream (erro de transporte ou 5xx).
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	switch b.state {
+	case StateHalfOpen:
+		b.releaseHalfOpen()
+		b.setState(StateOpen, now)
+	case StateClosed:
+		b.rollWindow(now)
+		b.requests++
+		b.failures++
+		b.consecutiveFailures++
+		if b.shouldTrip() {
+			b.setState(StateOpen, now)
+		}
+	}
+}
+
+// Release libera a reserva feita por Allow sem registrar resultado, por
+// exemplo quando o cliente cancela a requisição.
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateHalfOpen {
+		b.releaseHalfOpen()
+	}
+}
+
+// releaseHalfOpen devolve uma vaga de teste. Uma requisição reservada antes de
+// uma transição pode terminar depois dela, então o contador nunca fica negativo.
+func (b *CircuitBreaker) releaseHalfOpen() {
+	if b.halfOpenInFlight > 0 {
+		b.halfOpenInFlight--
+	}
+}
+
+func (b *CircuitBreaker) shouldTrip() bool {
+	if b.cfg.ConsecutiveFailures > 0 && b.consecutiveFailures >= b.cfg.ConsecutiveFailures {
+		return true
+	}
+	if b.cfg.ErrorRate > 0 && b.requests >= b.cfg.MinRequests {
+		return float64(b.failures)/float64(b.requests) >= b.cfg.ErrorRate
+	}
+	return false
+}
+
+// refresh passa de aberto para half-open quando o OpenTimeout termina.
+func (b *CircuitBreaker) refresh(now time.Time) {
+	if b.state == StateOpen && now.Sub(b.openedAt) >= b.cfg.OpenTimeout {
+		b.setState(StateHalfOpen, now)
+	}
+}
+
+// rollWindow zera os contadores da taxa de erro ao fim de cada janela.
+func (b *CircuitBreaker) rollWindow(now time.Time) {
+	if now.Sub(b.windowStart) >= b.cfg.Window {
+		b.windowStart = now
+		b.requests = 0
+		b.failures = 0
+	}
+}
+
+func (b *CircuitBreaker) setState(state BreakerState, now time.Time) {
+	if b.state == state {
+		return
+	}
+
+	from := b.state
+	b.state = state
+	b.transitions++
+	b.consecutiveFailures = 0
+	b.requests = 0
+	b.failures = 0
+	b.windowStart = now
+	b.halfOpenInFlight = 0
+	b.halfOpenSuccesses = 0
+	if state == StateOpen {
+		b.openedAt = now
+	}
+
+	if b.onStateChange != nil {
+		b.onStateChange(b.name, from, state)
+	}
+}
@@ -0,0 +1,170 @@
+package proxy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock é o relógio dos breakers de teste; só anda com Advance.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+type transition struct {
+	from, to BreakerState
+}
+
+func newTestBreaker(t *testing.T, cfg BreakerConfig) (*CircuitBreaker, *fakeClock, *[]transition) {
+	t.Helper()
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	transitions := &[]transition{}
+	b := NewCircuitBreaker("test", cfg, func(name string, from, to BreakerState) {
+		require.Equal(t, "test", name)
+		*transitions = append(*transitions, transition{from, to})
+	})
+	b.now = clock.Now
+	b.windowStart = clock.Now()
+	return b, clock, transitions
+}
+
+func fail(t *testing.T, b *CircuitBreaker, n int) {
+	t.Helper()
+	for range n {
+		require.NoError(t, b.Allow())
+		b.Failure()
+	}
+}
+
+func succeed(t *testing.T, b *CircuitBreaker, n int) {
+	t.Helper()
+	for range n {
+		require.NoError(t, b.Allow())
+		b.Success()
+	}
+}
+
+func TestBreakerConsecutiveFailures(t *testing.T) {
+	b, _, transitions := newTestBreaker(t, BreakerConfig{ConsecutiveFailures: 3})
+
+	// Um sucesso zera a sequência
+	fail(t, b, 2)
+	succeed(t, b, 1)
+	fail(t, b, 2)
+	require.Equal(t, StateClosed, b.State())
+
+	fail(t, b, 1)
+	require.Equal(t, StateOpen, b.State())
+	require.ErrorIs(t, b.Allow(), ErrCircuitOpen)
+	require.False(t, b.Ready())
+	require.Equal(t, []transition{{StateClosed, StateOpen}}, *transitions)
+}
+
+func TestBreakerErrorRate(t *testing.T) {
+	b, clock, _ := newTestBreaker(t, BreakerConfig{ErrorRate: 0.5, MinRequests: 10, Window: time.Minute})
+
+	// 100% de falhas, mas abaixo do mínimo de requisições
+	fail(t, b, 9)
+	require.Equal(t, StateClosed, b.State())
+
+	// Uma nova janela descarta as falhas anteriores
+	clock.Advance(time.Minute)
+	succeed(t, b, 6)
+	fail(t, b, 3)
+	require.Equal(t, StateClosed, b.State())
+
+	// 6 sucessos e 4 falhas: 40%, abaixo da taxa
+	fail(t, b, 1)
+	require.Equal(t, StateClosed, b.State())
+
+	// 6 sucessos e 6 falhas: 50%
+	fail(t, b, 2)
+	require.Equal(t, StateOpen, b.State())
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	cfg := BreakerConfig{ConsecutiveFailures: 1, OpenTimeout: 10 * time.Second, HalfOpenMaxRequests: 2}
+
+	t.Run("open timeout", func(t *testing.T) {
+		b, clock, _ := newTestBreaker(t, cfg)
+		fail(t, b, 1)
+
+		clock.Advance(9 * time.Second)
+		require.Equal(t, StateOpen, b.State())
+		clock.Advance(time.Second)
+		require.Equal(t, StateHalfOpen, b.State())
+	})
+
+	t.Run("probe limit", func(t *testing.T) {
+		b, clock, _ := newTestBreaker(t, cfg)
+		fail(t, b, 1)
+		clock.Advance(cfg.OpenTimeout)
+
+		require.NoError(t, b.Allow())
+		require.NoError(t, b.Allow())
+		require.False(t, b.Ready())
+		require.ErrorIs(t, b.Allow(), ErrCircuitOpen)
+	})
+
+	t.Run("success closes", func(t *testing.T) {
+		b, clock, transitions := newTestBreaker(t, cfg)
+		fail(t, b, 1)
+		clock.Advance(cfg.OpenTimeout)
+
+		succeed(t, b, 1)
+		require.Equal(t, StateHalfOpen, b.State())
+		succeed(t, b, 1)
+		require.Equal(t, StateClosed, b.State())
+		require.Equal(t, []transition{
+			{StateClosed, StateOpen},
+			{StateOpen, StateHalfOpen},
+			{StateHalfOpen, StateClosed},
+		}, *transitions)
+		require.Equal(t, uint64(3), b.Transitions())
+	})
+
+	t.Run("failure reopens", func(t *testing.T) {
+		b, clock, transitions := newTestBreaker(t, cfg)
+		fail(t, b, 1)
+		clock.Advance(cfg.OpenTimeout)
+
+		succeed(t, b, 1)
+		fail(t, b, 1)
+		require.Equal(t, StateOpen, b.State())
+		require.Equal(t, transition{StateHalfOpen, StateOpen}, (*transitions)[len(*transitions)-1])
+
+		// O OpenTimeout recomeça a contar da reabertura
+		clock.Advance(cfg.OpenTimeout - time.Second)
+		require.Equal(t, StateOpen, b.State())
+	})
+
+	t.Run("release frees probes", func(t *testing.T) {
+		b, clock, _ := newTestBreaker(t, cfg)
+		fail(t, b, 1)
+		clock.Advance(cfg.OpenTimeout)
+
+		for range 5 {
+			require.NoError(t, b.Allow())
+			require.NoError(t, b.Allow())
+			b.Release()
+			b.Release()
+		}
+		require.True(t, b.Ready())
+		require.Equal(t, StateHalfOpen, b.State())
+
+		// Um Release a mais não cria vagas extras
+		b.Release()
+		require.NoError(t, b.Allow())
+		require.NoError(t, b.Allow())
+		require.ErrorIs(t, b.Allow(), ErrCircuitOpen)
+	})
+}
@@ -0,0 +1,597 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Strategy define como o pool escolhe o upstream de cada requisição.
+type Strategy string
+
+const (
+	StrategyRoundRobin       Strategy = "round_robin"
+	StrategyLeastConnections Strategy = "least_connections"
+	StrategyConsistentHash   Strategy = "consistent_hash"
+)
+
+// Origens da chave usada pelo hash consistente.
+const (
+	HashByHeader   = "header"
+	HashByUsername = "username"
+)
+
+// _virtualNodes é o número de pontos de cada upstream no anel de hash.
+const _virtualNodes = 100
+
+var ErrNoHealthyUpstream = errors.New("no healthy upstream available")
+
+// BalancerConfig configura a estratégia de balanceamento de um pool.
+type BalancerConfig struct {
+	Strategy   Strategy `yaml:"strategy" json:"strategy"`
+	HashBy     string   `yaml:"hash_by" json:"hash_by"`
+	HashHeader string   `yaml:"hash_header" json:"hash_header"`
+}
+
+// HealthCheckConfig configura o health check ativo dos upstreams.
+// Sem Path, os health checks ficam desabilitados e todos os upstreams são
+// considerados saudáveis.
+type HealthCheckConfig struct {
+	Path               string        `yaml:"path" json:"path"`
+	Interval           time.Duration `yaml:"interval" json:"interval"`
+	Timeout            time.Duration `yaml:"timeout" json:"timeout"`
+	HealthyThreshold   int           `yaml:"healthy_threshold" json:"healthy_threshold"`
+	UnhealthyThreshold int           `yaml:"unhealthy_threshold" json:"unhealthy_threshold"`
+}
+
+// PoolConfig reúne tudo o que é necessário para montar um pool de upstreams.
+type PoolConfig struct {
+	Name        string
+	Targets     []string
+	Balancer    BalancerConfig
+	HealthCheck HealthCheckConfig
+	Breaker     BreakerConfig
+	Retry       RetryConfig
+	Transport   TransportConfig
+	// RetryBudget é compartilhado entre os pools; nil desabilita o limite global
+	RetryBudget *RetryBudget
+	// Rewrite, se definido, ajusta cada requisição já endereçada ao upstream
+	// (com o caminho final), antes do envio. É chamado a cada tentativa
+	Rewrite func(*http.Request)
+}
+
+// Upstream é uma réplica de um serviço de backend.
+type Upstream struct {
+	URL     *url.URL
+	proxy   *httputil.ReverseProxy
+	breaker *CircuitBreaker
+
+	healthy   atomic.Bool
+	active    atomic.Int64
+	successes int
+	failures  int
+}
+
+// Healthy indica se o upstream está em rotação.
+func (u *Upstream) Healthy() bool {
+	return u.healthy.Load()
+}
+
+// ActiveRequests retorna o número de requisições em andamento no upstream.
+func (u *Upstream) ActiveRequests() int64 {
+	return u.active.Load()
+}
+
+// Breaker retorna o circuit breaker do upstream, ou nil se estiver desabilitado.
+func (u *Upstream) Breaker() *CircuitBreaker {
+	return u.breaker
+}
+
+// available indica se o upstream pode receber requisições: está saudável e o
+// circuito não está aberto.
+func (u *Upstream) available() bool {
+	return u.Healthy() && (u.breaker == nil || u.breaker.Ready())
+}
+
+type ringEntry struct {
+	hash     uint32
+	upstream *Upstream
+}
+
+// Pool distribui requisições entre as réplicas de um serviço e remove de
+// rotação as que falham no health check.
+type Pool struct {
+	name      string
+	upstreams []*Upstream
+	balancer  BalancerConfig
+	health    HealthCheckConfig
+	retry     RetryConfig
+	budget    *RetryBudget
+	client    *http.Client
+	ring      []ringEntry
+	next      atomic.Uint64
+}
+
+// NewPool cria um pool com um proxy reverso (e um transport) por upstream.
+func NewPool(cfg PoolConfig) (*Pool, error) {
+	if len(cfg.Targets) == 0 {
+		return nil, fmt.Errorf("NewPool %s: at least one target is required", cfg.Name)
+	}
+
+	balancer, err := cfg.Balancer.withDefaults()
+	if err != nil {
+		return nil, fmt.Errorf("NewPool %s: %w", cfg.Name, err)
+	}
+
+	retry, err := cfg.Retry.withDefaults()
+	if err != nil {
+		return nil, fmt.Errorf("NewPool %s: %w", cfg.Name, err)
+	}
+
+	pool := &Pool{
+		name:     cfg.Name,
+		balancer: balancer,
+		health:   cfg.HealthCheck.withDefaults(),
+		retry:    retry,
+		budget:   cfg.RetryBudget,
+	}
+
+	for _, target := range cfg.Targets {
+		transport := NewTransport(cfg.Transport)
+		p, err := NewReverseProxy(target, transport)
+		if err != nil {
+			return nil, fmt.Errorf("NewPool %s: %w", cfg.Name, err)
+		}
+		if cfg.Rewrite != nil {
+			director := p.Director
+			p.Director = func(r *http.Request) {
+				director(r)
+				cfg.Rewrite(r)
+			}
+		}
+		u, _ := url.Parse(target)
+
+		upstream := &Upstream{URL: u, proxy: p}
+		if cfg.Breaker.Enabled() {
+			upstream.breaker = NewCircuitBreaker(cfg.Name+" "+u.Host, cfg.Breaker, logStateChange)
+		}
+		upstream.healthy.Store(true)
+		pool.upstreams = append(pool.upstreams, upstream)
+	}
+
+	if pool.health.Path != "" {
+		pool.client = &http.Client{
+			Transport: NewTransport(cfg.Transport),
+			Timeout:   pool.health.Timeout,
+		}
+	}
+
+	if balancer.Strategy == StrategyConsistentHash {
+		pool.buildRing()
+	}
+
+	return pool, nil
+}
+
+func (c BalancerConfig) withDefaults() (BalancerConfig, error) {
+	if c.Strategy == "" {
+		c.Strategy = StrategyRoundRobin
+	}
+
+	switch c.Strategy {
+	case StrategyRoundRobin, StrategyLeastConnections:
+		return c, nil
+	case StrategyConsistentHash:
+		if c.HashBy == "" {
+			c.HashBy = HashByUsername
+		}
+		if c.HashBy != HashByHeader && c.HashBy != HashByUsername {
+			return c, fmt.Errorf("unsupported hash_by %q", c.HashBy)
+		}
+		if c.HashBy == HashByHeader && c.HashHeader == "" {
+			return c, fmt.Errorf("hash_header is required when hash_by is %q", HashByHeader)
+		}
+		return c, nil
+	default:
+		return c, fmt.Errorf("unsupported balancer strategy %q", c.Strategy)
+	}
+}
+
+func (c HealthCheckConfig) withDefaults() HealthCheckConfig {
+	if c.Path != "" && !strings.HasPrefix(c.Path, "/") {
+		c.Path = "/" + c.Path
+	}
+	if c.Interval == 0 {
+		c.Interval = 10 * time.Second
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 2 * time.Second
+	}
+	if c.HealthyThreshold == 0 {
+		c.HealthyThreshold = 2
+	}
+	if c.UnhealthyThreshold == 0 {
+		c.UnhealthyThreshold = 3
+	}
+	return c
+}
+
+// Name retorna o nome do pool (o nome da rota).
+func (p *Pool) Name() string {
+	return p.name
+}
+
+// Balancer retorna a configuração de balanceamento efetiva do pool.
+func (p *Pool) Balancer() BalancerConfig {
+	return p.balancer
+}
+
+// Upstreams retorna as réplicas do pool.
+func (p *Pool) Upstreams() []*Upstream {
+	return p.upstreams
+}
+
+// ServeHTTP encaminha a requisição sem chave de hash.
+func (p *Pool) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.Serve(w, r, "")
+}
+
+// Serve encaminha a requisição para o upstream escolhido pela estratégia do
+// pool, repetindo-a conforme a política de retry da rota. key só é usada pelo
+// hash consistente.
+func (p *Pool) Serve(w http.ResponseWriter, r *http.Request, key string) {
+	if !p.retry.Enabled() || !p.retry.allowsMethod(r.Method) || r.Header.Get("Upgrade") != "" {
+		p.serveOnce(w, r, key)
+		return
+	}
+
+	body, ok, err := bufferBody(r, p.retry.MaxBodyBytes)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("read request body: %w", err))
+		return
+	}
+	if !ok {
+		p.serveOnce(w, r, key)
+		return
+	}
+
+	if p.budget != nil {
+		p.budget.Deposit()
+	}
+	shouldRetry := func(status int) bool {
+		return p.retry.retryableStatus(status) && (p.budget == nil || p.budget.Withdraw())
+	}
+
+	for attempt := 1; ; attempt++ {
+		if body != nil {
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		// A última tentativa escreve direto no cliente, seja qual for o status
+		if attempt == p.retry.MaxAttempts {
+			p.serveOnce(w, r, key)
+			return
+		}
+
+		rw := newRetryWriter(w, shouldRetry)
+		p.serveOnce(rw, r, key)
+		if !rw.discarded {
+			return
+		}
+
+		delay := p.retry.backoff(attempt)
+		log.Printf("pool %s: retrying %s %s in %s (attempt %d/%d)",
+			p.name, r.Method, r.URL.Path, delay, attempt+1, p.retry.MaxAttempts)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-r.Context().Done():
+			// O cliente desistiu durante a espera: a resposta descartada não
+			// chegou a ser escrita, então vai ao menos o status dela, como
+			// numa tentativa única
+			timer.Stop()
+			log.Printf("pool %s: %s %s: %v", p.name, r.Method, r.URL.Path, r.Context().Err())
+			w.WriteHeader(rw.status)
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// serveOnce faz uma única tentativa em um upstream disponível.
+func (p *Pool) serveOnce(w http.ResponseWriter, r *http.Request, key string) {
+	upstream, err := p.Next(key)
+	if err != nil {
+		// A abertura do circuito já é registrada na transição do breaker
+		if !errors.Is(err, ErrCircuitOpen) {
+			log.Printf("pool %s: %v", p.name, err)
+		}
+		writeJSONError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	breaker := upstream.breaker
+	if breaker != nil {
+		if err := breaker.Allow(); err != nil {
+			writeJSONError(w, http.StatusServiceUnavailable, err)
+			return
+		}
+	}
+
+	upstream.active.Add(1)
+	defer upstream.active.Add(-1)
+
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	upstream.proxy.ServeHTTP(rec, r)
+
+	if breaker == nil {
+		return
+	}
+	switch {
+	case r.Context().Err() != nil:
+		// Cancelamento do cliente não diz nada sobre a saúde do upstream
+		breaker.Release()
+	case rec.status >= http.StatusInternalServerError:
+		breaker.Failure()
+	default:
+		breaker.Success()
+	}
+}
+
+// Next escolhe um upstream disponível.
+func (p *Pool) Next(key string) (*Upstream, error) {
+	var (
+		upstream *Upstream
+		err      error
+	)
+	switch p.balancer.Strategy {
+	case StrategyLeastConnections:
+		upstream, err = p.leastConnections()
+	case StrategyConsistentHash:
+		upstream, err = p.consistentHash(key)
+	default:
+		upstream, err = p.roundRobin()
+	}
+
+	// Distingue "todos fora do ar" de "todos com o circuito aberto"
+	if errors.Is(err, ErrNoHealthyUpstream) && p.anyHealthy() {
+		return nil, ErrCircuitOpen
+	}
+	return upstream, err
+}
+
+func (p *Pool) anyHealthy() bool {
+	for _, upstream := range p.upstreams {
+		if upstream.Healthy() {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Pool) roundRobin() (*Upstream, error) {
+	n := uint64(len(p.upstreams))
+	start := p.next.Add(1) - 1
+	for i := uint64(0); i < n; i++ {
+		upstream := p.upstreams[(start+i)%n]
+		if upstream.available() {
+			return upstream, nil
+		}
+	}
+	return nil, ErrNoHealthyUpstream
+}
+
+func (p *Pool) leastConnections() (*Upstream, error) {
+	// Começa de uma posição rotativa para espalhar os empates
+	n := uint64(len(p.upstreams))
+	start := p.next.Add(1) - 1
+
+	var best *Upstream
+	for i := uint64(0); i < n; i++ {
+		upstream := p.upstreams[(start+i)%n]
+		if !upstream.available() {
+			continue
+		}
+		if best == nil || upstream.ActiveRequests() < best.ActiveRequests() {
+			best = upstream
+		}
+	}
+
+	if best == nil {
+		return nil, ErrNoHealthyUpstream
+	}
+	return best, nil
+}
+
+func (p *Pool) consistentHash(key string) (*Upstream, error) {
+	if key == "" {
+		return p.roundRobin()
+	}
+
+	h := hashKey(key)
+	idx := sort.Search(len(p.ring), func(i int) bool { return p.ring[i].hash >= h })
+
+	// Percorre o anel no sentido horário pulando réplicas fora de rotação
+	for i := 0; i < len(p.ring); i++ {
+		entry := p.ring[(idx+i)%len(p.ring)]
+		if entry.upstream.available() {
+			return entry.upstream, nil
+		}
+	}
+	return nil, ErrNoHealthyUpstream
+}
+
+func (p *Pool) buildRing() {
+	p.ring = make([]ringEntry, 0, len(p.upstreams)*_virtualNodes)
+	for _, upstream := range p.upstreams {
+		for i := 0; i < _virtualNodes; i++ {
+			p.ring = append(p.ring, ringEntry{
+				hash:     hashKey(upstream.URL.Host + "#" + strconv.Itoa(i)),
+				upstream: upstream,
+			})
+		}
+	}
+	sort.Slice(p.ring, func(i, j int) bool { return p.ring[i].hash < p.ring[j].hash })
+}
+
+// hashKey aplica o finalizador do murmur3 sobre o FNV-1a para espalhar melhor
+// chaves parecidas (como "user1", "user2") pelo anel.
+func hashKey(key string) uint32 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return uint32(x >> 32)
+}
+
+// StartHealthChecks executa os health checks ativos até ctx ser cancelado.
+// Não faz nada se o pool não tiver um path de health check configurado.
+func (p *Pool) StartHealthChecks(ctx context.Context) {
+	if p.health.Path == "" {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(p.health.Interval)
+		defer ticker.Stop()
+
+		p.checkAll(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.checkAll(ctx)
+			}
+		}
+	}()
+}
+
+func (p *Pool) checkAll(ctx context.Context) {
+	for _, upstream := range p.upstreams {
+		p.recordCheck(upstream, p.check(ctx, upstream))
+	}
+}
+
+func (p *Pool) check(ctx context.Context, upstream *Upstream) error {
+	target := upstream.URL.JoinPath(p.health.Path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// recordCheck aplica os limiares de sucesso/falha antes de mudar o estado do
+// upstream. É chamado apenas pela goroutine de health check.
+func (p *Pool) recordCheck(upstream *Upstream, err error) {
+	if err != nil {
+		upstream.successes = 0
+		upstream.failures++
+		if upstream.Healthy() && upstream.failures >= p.health.UnhealthyThreshold {
+			upstream.healthy.Store(false)
+			log.Printf("pool %s: upstream %s removed from rotation: %v", p.name, upstream.URL.Host, err)
+		}
+		return
+	}
+
+	upstream.failures = 0
+	upstream.successes++
+	if !upstream.Healthy() && upstream.successes >= p.health.HealthyThreshold {
+		upstream.healthy.Store(true)
+		log.Printf("pool %s: upstream %s back in rotation", p.name, upstream.URL.Host)
+	}
+}
+
+// UpstreamStatus é o estado de um upstream exposto para monitoramento.
+type UpstreamStatus struct {
+	URL            string         `json:"url"`
+	Healthy        bool           `json:"healthy"`
+	ActiveRequests int64          `json:"active_requests"`
+	Breaker        *BreakerStatus `json:"breaker,omitempty"`
+}
+
+// BreakerStatus é o estado de um circuit breaker exposto para monitoramento.
+type BreakerStatus struct {
+	State       BreakerState `json:"state"`
+	Transitions uint64       `json:"transitions"`
+}
+
+// Status retorna o estado atual de cada upstream do pool.
+func (p *Pool) Status() []UpstreamStatus {
+	status := make([]UpstreamStatus, 0, len(p.upstreams))
+	for _, upstream := range p.upstreams {
+		s := UpstreamStatus{
+			URL:            upstream.URL.String(),
+			Healthy:        upstream.Healthy(),
+			ActiveRequests: upstream.ActiveRequests(),
+		}
+		if upstream.breaker != nil {
+			s.Breaker = &BreakerStatus{
+				State:       upstream.breaker.State(),
+				Transitions: upstream.breaker.Transitions(),
+			}
+		}
+		status = append(status, s)
+	}
+	return status
+}
+
+func logStateChange(name string, from, to BreakerState) {
+	log.Printf("circuit breaker %s: %s -> %s", name, from, to)
+}
+
+// statusRecorder guarda o status da resposta para alimentar o circuit breaker.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap permite que o http.ResponseController do ReverseProxy alcance o
+// writer original (flush de respostas em streaming, por exemplo).
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,218 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testUpstream é um backend cujo health check pode ser derrubado no meio do
+// teste.
+type testUpstream struct {
+	*httptest.Server
+	down atomic.Bool
+}
+
+func newTestUpstream(t *testing.T, name string) *testUpstream {
+	t.Helper()
+	u := &testUpstream{}
+	u.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" && u.down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(name))
+	}))
+	t.Cleanup(u.Close)
+	return u
+}
+
+func newTestPool(t *testing.T, cfg PoolConfig, upstreams ...*testUpstream) *Pool {
+	t.Helper()
+	for _, u := range upstreams {
+		cfg.Targets = append(cfg.Targets, u.URL)
+	}
+	if cfg.Name == "" {
+		cfg.Name = "test"
+	}
+	pool, err := NewPool(cfg)
+	require.NoError(t, err)
+	return pool
+}
+
+func TestPoolRoundRobin(t *testing.T) {
+	a, b, c := newTestUpstream(t, "a"), newTestUpstream(t, "b"), newTestUpstream(t, "c")
+	pool := newTestPool(t, PoolConfig{}, a, b, c)
+
+	var got []string
+	for range 6 {
+		upstream, err := pool.Next("")
+		require.NoError(t, err)
+		got = append(got, "http://"+upstream.URL.Host)
+	}
+	require.Equal(t, []string{a.URL, b.URL, c.URL, a.URL, b.URL, c.URL}, got)
+
+	// A requisição chega de fato ao upstream escolhido
+	rec := httptest.NewRecorder()
+	pool.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "a", rec.Body.String())
+}
+
+func TestPoolLeastConnections(t *testing.T) {
+	a, b, c := newTestUpstream(t, "a"), newTestUpstream(t, "b"), newTestUpstream(t, "c")
+	pool := newTestPool(t, PoolConfig{Balancer: BalancerConfig{Strategy: StrategyLeastConnections}}, a, b, c)
+
+	upstreams := pool.Upstreams()
+	upstreams[0].active.Store(5)
+	upstreams[1].active.Store(1)
+	upstreams[2].active.Store(3)
+
+	for range 5 {
+		upstream, err := pool.Next("")
+		require.NoError(t, err)
+		require.Same(t, upstreams[1], upstream)
+	}
+
+	// Fora de rotação, o menos carregado é ignorado
+	upstreams[1].healthy.Store(false)
+	upstream, err := pool.Next("")
+	require.NoError(t, err)
+	require.Same(t, upstreams[2], upstream)
+}
+
+func TestPoolConsistentHash(t *testing.T) {
+	a, b, c := newTestUpstream(t, "a"), newTestUpstream(t, "b"), newTestUpstream(t, "c")
+	pool := newTestPool(t, PoolConfig{Balancer: BalancerConfig{Strategy: StrategyConsistentHash}}, a, b, c)
+
+	// A mesma chave vai sempre para o mesmo upstream
+	owners := make(map[string]*Upstream)
+	for i := range 50 {
+		key := fmt.Sprintf("user%d", i)
+		first, err := pool.Next(key)
+		require.NoError(t, err)
+		for range 3 {
+			again, err := pool.Next(key)
+			require.NoError(t, err)
+			require.Same(t, first, again)
+		}
+		owners[key] = first
+	}
+
+	// Com um nó fora de rotação, só as chaves dele mudam de dono
+	ejected := pool.Upstreams()[0]
+	ejected.healthy.Store(false)
+	moved := 0
+	for key, owner := range owners {
+		upstream, err := pool.Next(key)
+		require.NoError(t, err)
+		require.NotSame(t, ejected, upstream)
+		if owner == ejected {
+			moved++
+			continue
+		}
+		require.Same(t, owner, upstream, "key %s", key)
+	}
+	require.NotZero(t, moved)
+
+	// De volta à rotação, as chaves voltam para ele
+	ejected.healthy.Store(true)
+	for key, owner := range owners {
+		upstream, err := pool.Next(key)
+		require.NoError(t, err)
+		require.Same(t, owner, upstream, "key %s", key)
+	}
+}
+
+func TestPoolHealthChecks(t *testing.T) {
+	a, b := newTestUpstream(t, "a"), newTestUpstream(t, "b")
+	pool := newTestPool(t, PoolConfig{HealthCheck: HealthCheckConfig{
+		Path:               "health",
+		HealthyThreshold:   2,
+		UnhealthyThreshold: 3,
+	}}, a, b)
+	ctx := context.Background()
+	unhealthy := pool.Upstreams()[0]
+
+	a.down.Store(true)
+	for range 2 {
+		pool.checkAll(ctx)
+		require.True(t, unhealthy.Healthy(), "removed before the unhealthy threshold")
+	}
+	pool.checkAll(ctx)
+	require.False(t, unhealthy.Healthy())
+
+	for range 4 {
+		upstream, err := pool.Next("")
+		require.NoError(t, err)
+		require.Equal(t, b.URL, "http://"+upstream.URL.Host)
+	}
+
+	a.down.Store(false)
+	pool.checkAll(ctx)
+	require.False(t, unhealthy.Healthy(), "back before the healthy threshold")
+	pool.checkAll(ctx)
+	require.True(t, unhealthy.Healthy())
+}
+
+func TestPoolNoUpstreamErrors(t *testing.T) {
+	a, b := newTestUpstream(t, "a"), newTestUpstream(t, "b")
+	pool := newTestPool(t, PoolConfig{Breaker: BreakerConfig{ConsecutiveFailures: 1}}, a, b)
+	upstreams := pool.Upstreams()
+
+	// Todos saudáveis mas com o circuito aberto
+	for _, upstream := range upstreams {
+		require.NoError(t, upstream.breaker.Allow())
+		upstream.breaker.Failure()
+	}
+	_, err := pool.Next("")
+	require.ErrorIs(t, err, ErrCircuitOpen)
+
+	rec := httptest.NewRecorder()
+	pool.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.Contains(t, rec.Body.String(), ErrCircuitOpen.Error())
+
+	// Todos fora de rotação
+	for _, upstream := range upstreams {
+		upstream.healthy.Store(false)
+	}
+	_, err = pool.Next("")
+	require.ErrorIs(t, err, ErrNoHealthyUpstream)
+}
+
+func TestPoolStatus(t *testing.T) {
+	a, b := newTestUpstream(t, "a"), newTestUpstream(t, "b")
+	pool := newTestPool(t, PoolConfig{Breaker: BreakerConfig{ConsecutiveFailures: 1}}, a, b)
+	upstreams := pool.Upstreams()
+
+	upstreams[0].active.Store(2)
+	upstreams[1].healthy.Store(false)
+	require.NoError(t, upstreams[1].breaker.Allow())
+	upstreams[1].breaker.Failure()
+
+	status := pool.Status()
+	require.Equal(t, []UpstreamStatus{
+		{URL: a.URL, Healthy: true, ActiveRequests: 2, Breaker: &BreakerStatus{State: StateClosed}},
+		{URL: b.URL, Healthy: false, Breaker: &BreakerStatus{State: StateOpen, Transitions: 1}},
+	}, status)
+
+	// Formato publicado em /_gateway/upstreams
+	data, err := json.Marshal(status[1])
+	require.NoError(t, err)
+	require.JSONEq(t, fmt.Sprintf(
+		`{"url":%q,"healthy":false,"active_requests":0,"breaker":{"state":"open","transitions":1}}`, b.URL),
+		string(data))
+
+	// Sem breaker, o campo é omitido
+	plain := newTestPool(t, PoolConfig{}, a)
+	data, err = json.Marshal(plain.Status())
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "breaker")
+}
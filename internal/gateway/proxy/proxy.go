@@ -1,16 +1,27 @@
 package proxy
 
 import (
+	"log"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 )
 
 // NewReverseProxy cria um handler de proxy reverso para um serviço de backend.
-func NewReverseProxy(targetURL string) (http.Handler, error) {
+// O proxy deve ser criado uma única vez por upstream: o transport informado é
+// compartilhado entre as requisições e mantém as conexões abertas. Se transport
+// for nil, http.DefaultTransport é usado.
+func NewReverseProxy(targetURL string, transport http.RoundTripper) (*httputil.ReverseProxy, error) {
 	target, err := url.Parse(targetURL)
 	if err != nil {
 		return nil, err
 	}
-	return httputil.NewSingleHostReverseProxy(target), nil
+
+	p := httputil.NewSingleHostReverseProxy(target)
+	p.Transport = transport
+	p.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		log.Printf("proxy %s: %s %s: %v", target.Host, r.Method, r.URL.Path, err)
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	return p, nil
 }
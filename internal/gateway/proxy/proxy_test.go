@@ -0,0 +1,74 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newBenchmarkUpstream(b *testing.B) *httptest.Server {
+	b.Helper()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	}))
+	b.Cleanup(upstream.Close)
+	return upstream
+}
+
+func runProxyBenchmark(b *testing.B, handler http.Handler) {
+	b.Helper()
+	gateway := httptest.NewServer(handler)
+	defer gateway.Close()
+
+	client := gateway.Client()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resp, err := client.Get(gateway.URL + "/users/123")
+		require.NoError(b, err)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		require.Equal(b, http.StatusOK, resp.StatusCode)
+	}
+}
+
+// BenchmarkPerRequestProxy reproduz o comportamento antigo do router: url.Parse
+// e um proxy novo a cada requisição.
+func BenchmarkPerRequestProxy(b *testing.B) {
+	upstream := newBenchmarkUpstream(b)
+
+	runProxyBenchmark(b, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target, _ := url.Parse(upstream.URL)
+		p := httputil.NewSingleHostReverseProxy(target)
+		p.ServeHTTP(w, r)
+	}))
+}
+
+// BenchmarkPerRequestTransport mostra o custo de criar o transport ajustado a
+// cada requisição: nenhuma conexão é reaproveitada.
+func BenchmarkPerRequestTransport(b *testing.B) {
+	upstream := newBenchmarkUpstream(b)
+
+	runProxyBenchmark(b, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		transport := NewTransport(TransportConfig{})
+		defer transport.CloseIdleConnections()
+
+		p, err := NewReverseProxy(upstream.URL, transport)
+		require.NoError(b, err)
+		p.ServeHTTP(w, r)
+	}))
+}
+
+func BenchmarkSharedProxy(b *testing.B) {
+	upstream := newBenchmarkUpstream(b)
+
+	p, err := NewReverseProxy(upstream.URL, NewTransport(TransportConfig{}))
+	require.NoError(b, err)
+
+	runProxyBenchmark(b, p)
+}
@@ -0,0 +1,229 @@
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// _idempotentMethods são os únicos métodos que podem ser repetidos com segurança.
+var _idempotentMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodHead:   true,
+	http.MethodPut:    true,
+	http.MethodDelete: true,
+}
+
+// RetryConfig define a política de retry de uma rota. Com MaxAttempts menor
+// que 2 a política fica desabilitada.
+type RetryConfig struct {
+	// MaxAttempts conta a tentativa original
+	MaxAttempts     int           `yaml:"max_attempts" json:"max_attempts"`
+	Methods         []string      `yaml:"methods" json:"methods"`
+	RetryableStatus []int         `yaml:"retryable_status" json:"retryable_status"`
+	BaseBackoff     time.Duration `yaml:"base_backoff" json:"base_backoff"`
+	MaxBackoff      time.Duration `yaml:"max_backoff" json:"max_backoff"`
+	// MaxBodyBytes limita o corpo guardado em memória para ser reenviado;
+	// requisições com corpo maior seguem sem retry
+	MaxBodyBytes int64 `yaml:"max_body_bytes" json:"max_body_bytes"`
+}
+
+// Enabled indica se a política permite mais de uma tentativa.
+func (c RetryConfig) Enabled() bool {
+	return c.MaxAttempts > 1
+}
+
+func (c RetryConfig) withDefaults() (RetryConfig, error) {
+	if len(c.Methods) == 0 {
+		c.Methods = []string{http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete}
+	}
+	for i, method := range c.Methods {
+		method = strings.ToUpper(method)
+		if !_idempotentMethods[method] {
+			return c, fmt.Errorf("retry: method %s is not idempotent", method)
+		}
+		c.Methods[i] = method
+	}
+	if len(c.RetryableStatus) == 0 {
+		c.RetryableStatus = []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+	}
+	if c.BaseBackoff == 0 {
+		c.BaseBackoff = 50 * time.Millisecond
+	}
+	if c.MaxBackoff == 0 {
+		c.MaxBackoff = time.Second
+	}
+	if c.MaxBodyBytes == 0 {
+		c.MaxBodyBytes = 1 << 20
+	}
+	return c, nil
+}
+
+func (c RetryConfig) allowsMethod(method string) bool {
+	for _, m := range c.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func (c RetryConfig) retryableStatus(status int) bool {
+	for _, s := range c.RetryableStatus {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff calcula a espera antes da tentativa seguinte (exponencial com full jitter).
+func (c RetryConfig) backoff(attempt int) time.Duration {
+	ceiling := c.BaseBackoff << (attempt - 1)
+	if ceiling <= 0 || ceiling > c.MaxBackoff {
+		ceiling = c.MaxBackoff
+	}
+	return rand.N(ceiling) + 1
+}
+
+// RetryBudget limita os retries a uma fração das requisições, para que uma
+// falha no backend não seja amplificada pelo próprio gateway. Cada requisição
+// deposita ratio no saldo e cada retry consome 1; minPerSecond garante alguns
+// retries mesmo com pouco tráfego.
+type RetryBudget struct {
+	ratio   float64
+	reserve *rate.Limiter
+
+	mu      sync.Mutex
+	balance float64
+	max     float64
+}
+
+// NewRetryBudget cria um orçamento de retries compartilhado entre as rotas.
+func NewRetryBudget(ratio float64, minPerSecond int) *RetryBudget {
+	return &RetryBudget{
+		ratio:   ratio,
+		reserve: rate.NewLimiter(rate.Limit(minPerSecond), max(minPerSecond, 1)),
+		// O saldo acumulado fica limitado para não liberar uma rajada de
+		// retries depois de um período longo sem falhas
+		max: max(ratio*100, 1),
+	}
+}
+
+// Deposit registra uma requisição elegível a retry.
+func (b *RetryBudget) Deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.balance = min(b.balance+b.ratio, b.max)
+}
+
+// Withdraw reserva um retry; retorna false se o orçamento estiver esgotado.
+func (b *RetryBudget) Withdraw() bool {
+	b.mu.Lock()
+	if b.balance >= 1 {
+		b.balance--
+		b.mu.Unlock()
+		return true
+	}
+	b.mu.Unlock()
+
+	return b.reserve.Allow()
+}
+
+// bufferBody lê o corpo da requisição para que possa ser reenviado. Se o corpo
+// passar de limit, a requisição é remontada intacta e ok é false.
+func bufferBody(r *http.Request, limit int64) (body []byte, ok bool, err error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, true, nil
+	}
+	if r.ContentLength > limit {
+		return nil, false, nil
+	}
+
+	body, err = io.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		return nil, false, err
+	}
+	if int64(len(body)) > limit {
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+		return nil, false, nil
+	}
+
+	_ = r.Body.Close()
+	return body, true, nil
+}
+
+// retryWriter segura a resposta de uma tentativa que ainda pode ser repetida.
+// Se o status for retentável (e houver orçamento), a resposta é descartada;
+// caso contrário, cabeçalhos e corpo seguem para o cliente normalmente.
+type retryWriter struct {
+	w           http.ResponseWriter
+	header      http.Header
+	shouldRetry func(status int) bool
+
+	wroteHeader bool
+	discarded   bool
+	// status é o status da resposta, inclusive da descartada
+	status int
+}
+
+func newRetryWriter(w http.ResponseWriter, shouldRetry func(status int) bool) *retryWriter {
+	return &retryWriter{w: w, header: make(http.Header), shouldRetry: shouldRetry}
+}
+
+func (rw *retryWriter) Header() http.Header {
+	return rw.header
+}
+
+func (rw *retryWriter) WriteHeader(status int) {
+	if rw.wroteHeader {
+		return
+	}
+	rw.wroteHeader = true
+	rw.status = status
+
+	if rw.shouldRetry(status) {
+		rw.discarded = true
+		return
+	}
+
+	dst := rw.w.Header()
+	for k, v := range rw.header {
+		dst[k] = v
+	}
+	rw.w.WriteHeader(status)
+}
+
+func (rw *retryWriter) Write(p []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if rw.discarded {
+		return len(p), nil
+	}
+	return rw.w.Write(p)
+}
+
+// Flush repassa o flush apenas de respostas que foram enviadas ao cliente.
+func (rw *retryWriter) Flush() {
+	if rw.wroteHeader && !rw.discarded {
+		_ = http.NewResponseController(rw.w).Flush()
+	}
+}
+
+// Unwrap permite que o http.ResponseController alcance o writer original,
+// como em statusRecorder. Flush continua passando por retryWriter.
+func (rw *retryWriter) Unwrap() http.ResponseWriter {
+	return rw.w
+}
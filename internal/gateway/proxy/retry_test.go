@@ -0,0 +1,272 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// flakyUpstream responde 503 nas primeiras failures requisições e guarda o
+// corpo de cada uma.
+type flakyUpstream struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	failures int
+	bodies   []string
+}
+
+func newFlakyUpstream(t *testing.T, failures int) *flakyUpstream {
+	t.Helper()
+	u := &flakyUpstream{failures: failures}
+	u.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		u.mu.Lock()
+		u.bodies = append(u.bodies, string(body))
+		fail := len(u.bodies) <= u.failures
+		u.mu.Unlock()
+
+		if fail {
+			w.Header().Set("X-Failed-Attempt", "true")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("upstream unavailable"))
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(u.Close)
+	return u
+}
+
+func (u *flakyUpstream) attempts() []string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return append([]string(nil), u.bodies...)
+}
+
+func newRetryPool(t *testing.T, upstream *flakyUpstream, retry RetryConfig, budget *RetryBudget) *Pool {
+	t.Helper()
+	retry.BaseBackoff = time.Millisecond
+	retry.MaxBackoff = time.Millisecond
+	pool, err := NewPool(PoolConfig{Name: "test", Targets: []string{upstream.URL}, Retry: retry, RetryBudget: budget})
+	require.NoError(t, err)
+	return pool
+}
+
+func TestRetryReplaysBody(t *testing.T) {
+	upstream := newFlakyUpstream(t, 2)
+	pool := newRetryPool(t, upstream, RetryConfig{MaxAttempts: 3}, nil)
+
+	body := `{"name":"relatório","items":[1,2,3]}`
+	rec := httptest.NewRecorder()
+	pool.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/docs/1", strings.NewReader(body)))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, []string{body, body, body}, upstream.attempts())
+
+	// Nada das tentativas descartadas chega ao cliente
+	require.Equal(t, "ok", rec.Body.String())
+	require.Empty(t, rec.Header().Get("X-Failed-Attempt"))
+}
+
+func TestRetryLastAttemptIsDelivered(t *testing.T) {
+	upstream := newFlakyUpstream(t, 5)
+	pool := newRetryPool(t, upstream, RetryConfig{MaxAttempts: 3}, nil)
+
+	rec := httptest.NewRecorder()
+	pool.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs/1", nil))
+
+	require.Len(t, upstream.attempts(), 3)
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.Equal(t, "upstream unavailable", rec.Body.String())
+}
+
+func TestRetryClientCanceledDuringBackoff(t *testing.T) {
+	upstream := newFlakyUpstream(t, 5)
+	pool, err := NewPool(PoolConfig{Name: "test", Targets: []string{upstream.URL}, Retry: RetryConfig{
+		MaxAttempts: 3,
+		BaseBackoff: time.Minute,
+		MaxBackoff:  time.Minute,
+	}})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	rec := httptest.NewRecorder()
+	pool.ServeHTTP(rec, httptest.NewRequestWithContext(ctx, http.MethodGet, "/docs/1", nil))
+
+	// A resposta descartada não chega, mas o status dela sim
+	require.Len(t, upstream.attempts(), 1)
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.Empty(t, rec.Body.String())
+}
+
+func TestRetryBodyOverLimit(t *testing.T) {
+	upstream := newFlakyUpstream(t, 5)
+	pool := newRetryPool(t, upstream, RetryConfig{MaxAttempts: 3, MaxBodyBytes: 8}, nil)
+
+	body := strings.Repeat("x", 20)
+	rec := httptest.NewRecorder()
+	pool.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/docs/1", strings.NewReader(body)))
+
+	// Uma única tentativa, com o corpo inteiro
+	require.Equal(t, []string{body}, upstream.attempts())
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestBufferBody(t *testing.T) {
+	body := strings.Repeat("y", 20)
+
+	// Sem Content-Length (chunked), o limite só é descoberto na leitura e o
+	// corpo é remontado intacto
+	req := httptest.NewRequest(http.MethodPut, "/", io.NopCloser(strings.NewReader(body)))
+	req.ContentLength = -1
+	buffered, ok, err := bufferBody(req, 8)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Nil(t, buffered)
+	rest, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	require.Equal(t, body, string(rest))
+
+	req = httptest.NewRequest(http.MethodPut, "/", strings.NewReader(body))
+	buffered, ok, err = bufferBody(req, 20)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, body, string(buffered))
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	buffered, ok, err = bufferBody(req, 8)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Nil(t, buffered)
+}
+
+func TestRetryWriter(t *testing.T) {
+	retryable := func(status int) bool { return status == http.StatusBadGateway }
+
+	for _, status := range []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout} {
+		rec := httptest.NewRecorder()
+		rw := newRetryWriter(rec, func(s int) bool { return s == status })
+		rw.Header().Set("X-Upstream", "a")
+		rw.WriteHeader(status)
+		_, err := rw.Write([]byte("partial"))
+		require.NoError(t, err)
+		rw.Flush()
+
+		require.True(t, rw.discarded)
+		require.False(t, rec.Flushed)
+		require.Empty(t, rec.Header().Get("X-Upstream"))
+		require.Zero(t, rec.Body.Len())
+	}
+
+	rec := httptest.NewRecorder()
+	rw := newRetryWriter(rec, retryable)
+	rw.Header().Set("X-Upstream", "a")
+	_, err := rw.Write([]byte("fine"))
+	require.NoError(t, err)
+
+	require.False(t, rw.discarded)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "a", rec.Header().Get("X-Upstream"))
+	require.Equal(t, "fine", rec.Body.String())
+
+	// http.ResponseController alcança o writer original por Unwrap
+	dw := &deadlineWriter{ResponseRecorder: httptest.NewRecorder()}
+	deadline := time.Now().Add(time.Minute)
+	require.NoError(t, http.NewResponseController(newRetryWriter(dw, retryable)).SetWriteDeadline(deadline))
+	require.Equal(t, deadline, dw.deadline)
+}
+
+// deadlineWriter aceita SetWriteDeadline, como o writer de uma conexão real.
+type deadlineWriter struct {
+	*httptest.ResponseRecorder
+	deadline time.Time
+}
+
+func (w *deadlineWriter) SetWriteDeadline(deadline time.Time) error {
+	w.deadline = deadline
+	return nil
+}
+
+func TestRetryNonIdempotentMethods(t *testing.T) {
+	_, err := RetryConfig{MaxAttempts: 3, Methods: []string{"post"}}.withDefaults()
+	require.ErrorContains(t, err, "not idempotent")
+
+	upstream := newFlakyUpstream(t, 5)
+	pool := newRetryPool(t, upstream, RetryConfig{MaxAttempts: 3}, nil)
+
+	for _, method := range []string{http.MethodPost, http.MethodPatch} {
+		rec := httptest.NewRecorder()
+		pool.ServeHTTP(rec, httptest.NewRequest(method, "/docs", bytes.NewBufferString("{}")))
+		require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	}
+	require.Len(t, upstream.attempts(), 2)
+}
+
+func TestRetryBackoff(t *testing.T) {
+	cfg := RetryConfig{BaseBackoff: 10 * time.Millisecond, MaxBackoff: 100 * time.Millisecond}
+
+	for attempt := 1; attempt <= 10; attempt++ {
+		ceiling := min(cfg.BaseBackoff<<(attempt-1), cfg.MaxBackoff)
+		for range 50 {
+			delay := cfg.backoff(attempt)
+			require.Positive(t, delay)
+			require.LessOrEqual(t, delay, ceiling, "attempt %d", attempt)
+		}
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	// Sem reserva: só o saldo depositado pelas requisições
+	budget := NewRetryBudget(0.5, 0)
+	// Com limite zero o rate.Limiter ainda tem um token de burst; gasta antes
+	require.True(t, budget.Withdraw())
+	require.False(t, budget.Withdraw())
+
+	budget.Deposit()
+	require.False(t, budget.Withdraw())
+	budget.Deposit()
+	require.True(t, budget.Withdraw())
+	require.False(t, budget.Withdraw())
+
+	// O saldo acumulado tem teto
+	for range 1000 {
+		budget.Deposit()
+	}
+	withdrawn := 0
+	for budget.Withdraw() {
+		withdrawn++
+	}
+	require.Equal(t, 50, withdrawn)
+}
+
+func TestRetryBudgetStopsRetries(t *testing.T) {
+	upstream := newFlakyUpstream(t, 100)
+	// Cada requisição deposita 0.1 e a reserva libera um único retry
+	budget := NewRetryBudget(0.1, 1)
+	pool := newRetryPool(t, upstream, RetryConfig{MaxAttempts: 3}, budget)
+
+	rec := httptest.NewRecorder()
+	pool.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs/1", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.Len(t, upstream.attempts(), 2)
+
+	// Orçamento esgotado: a resposta da primeira tentativa vai para o cliente
+	rec = httptest.NewRecorder()
+	pool.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs/1", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.Equal(t, "upstream unavailable", rec.Body.String())
+	require.Len(t, upstream.attempts(), 3)
+}
@@ -1,90 +1,265 @@
 package router
 
 import (
+	"context"
 	"fmt"
-	"net/http/httputil"
-	"net/url"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"api--sigacore-gateway/internal/gateway/middleware"
+	"api--sigacore-gateway/internal/gateway/proxy"
+	"api--sigacore-gateway/internal/shared/identity"
+	sharedmw "api--sigacore-gateway/internal/shared/middleware"
+	"api--sigacore-gateway/internal/token"
 	"api--sigacore-gateway/internal/util"
 )
 
-// func setupGatewayMiddlewares() error {
-// 	r := gin.Default()
-// 	// Middleware global de whitelist de IPs
-// 	// ipWhitelistMiddleware := middleware.IPWhitelist(r.config.AllowedIPs...)
+// MiddlewareFactory cria o middleware de uma rota a partir da sua configuração.
+// É chamada uma vez por rota, então cada rota tem o seu próprio estado (por
+// exemplo, o seu próprio rate limiter).
+type MiddlewareFactory func(route RouteConfig) (gin.HandlerFunc, error)
 
-// 	// Middleware global de rate limiting
-// 	rateLimiterMiddleware := middleware.RateLimiter(rate.Limit(5), 10) // 5 req/s, burst de 10
+// Middlewares mapeia os nomes usados na tabela de rotas para as factories.
+type Middlewares map[string]MiddlewareFactory
 
-// 	// r.router.Use(ipWhitelistMiddleware)
-// 	r.Use(rateLimiterMiddleware)
+// SetupGatewayRoutes monta o engine do gateway a partir da tabela de rotas.
+// Os health checks dos upstreams rodam até ctx ser cancelado.
+func SetupGatewayRoutes(ctx context.Context, cfg util.Config, table RouteTable, middlewares Middlewares) (*gin.Engine, error) {
+	router := gin.Default()
+	// Por padrão o gin confia em X-Forwarded-For vindo de qualquer origem; o
+	// IP do cliente (usado no rate limiting e no balanceamento) só pode vir
+	// de TRUSTED_PROXIES
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("SetupGatewayRoutes: %w", err)
+	}
 
-// 	return nil
-// }
+	// Política CORS padrão; cada rota pode substituir parte dela
+	defaultCORS := corsFromConfig(cfg)
 
-func SetupGatewayRoutes(cfg util.Config) *gin.Engine {
-	router := gin.Default()
+	// Assina a identidade do usuário autenticado para os backends. Sem
+	// GATEWAY_IDENTITY_KEY, os cabeçalhos de identidade só são descartados
+	var signRequest func(*http.Request)
+	if cfg.GatewayIdentityKey != "" {
+		signer, err := identity.NewSigner(cfg.GatewayIdentityKey, cfg.GatewayIdentityMaxAge)
+		if err != nil {
+			return nil, fmt.Errorf("SetupGatewayRoutes: %w", err)
+		}
+		signRequest = signIdentity(signer)
+	}
 
-	// Configurar CORS - mais específico para evitar problemas com preflight
-	router.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "http://localhost:3000")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
-		c.Header("Access-Control-Allow-Credentials", "true")
-		c.Header("Access-Control-Max-Age", "86400") // Cache preflight por 24h
-
-		// Responder imediatamente para OPTIONS (preflight)
-		if c.Request.Method == "OPTIONS" {
-			fmt.Printf("🎯 CORS: OPTIONS preflight para %s\n", c.Request.URL.Path)
-			c.AbortWithStatus(204)
-			return
+	// Orçamento global: os retries de todas as rotas disputam o mesmo saldo
+	retryBudget := proxy.NewRetryBudget(cfg.RetryBudgetRatio, cfg.RetryBudgetMinPerSecond)
+
+	pools := make([]*proxy.Pool, 0, len(table.Routes))
+	for _, route := range table.Routes {
+		handlers, err := routeChain(route, middlewares)
+		if err != nil {
+			return nil, err
 		}
+		// CORS vem antes dos demais middlewares para que o preflight não
+		// passe por autenticação ou rate limiting
+		corsHandler := middleware.Adapt(middleware.CORS(defaultCORS.Override(route.CORS)))
+		handlers = append([]gin.HandlerFunc{corsHandler}, handlers...)
 
-		c.Next()
-	})
+		pool, err := proxy.NewPool(proxy.PoolConfig{
+			Name:        route.Name,
+			Targets:     route.Upstreams,
+			Balancer:    route.Balancer,
+			HealthCheck: route.HealthCheck,
+			Breaker:     route.CircuitBreaker,
+			Retry:       route.Retry,
+			Transport:   route.Transport,
+			RetryBudget: retryBudget,
+			Rewrite:     signRequest,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("route %q: %w", route.Name, err)
+		}
+		pool.StartHealthChecks(ctx)
+		pools = append(pools, pool)
+		handlers = append(handlers, newRouteHandler(route, pool))
 
-	// proxies
-	router.Any("/users/*path", gin.HandlerFunc(func(ctx *gin.Context) {
-		target, _ := url.Parse("http://localhost:8081")
-		proxy := httputil.NewSingleHostReverseProxy(target)
+		for _, path := range []string{route.Prefix, route.Prefix + "/*path"} {
+			if len(route.Methods) == 0 {
+				router.Any(path, handlers...)
+				continue
+			}
+			for _, method := range route.Methods {
+				router.Handle(method, path, handlers...)
+			}
+			// Sem OPTIONS na rota, o preflight ainda precisa ser respondido; o
+			// CORS encerra o preflight e deixa passar os demais OPTIONS, que
+			// recebem 405
+			if !slices.Contains(route.Methods, http.MethodOptions) {
+				router.OPTIONS(path, corsHandler, methodNotAllowed(route.Methods))
+			}
+		}
 
-		proxy.ServeHTTP(ctx.Writer, ctx.Request)
-	}))
+		log.Printf("🔀 Rota %s: %s %s -> %s (%s) middlewares: %s", route.Name, route.Prefix,
+			describeMethods(route.Methods), strings.Join(route.Upstreams, ", "),
+			pool.Balancer().Strategy, describeChain(route.Middlewares))
+	}
 
-	// client service
-	router.Any("/clientes/*path", gin.HandlerFunc(func(ctx *gin.Context) {
-		// O prefixo do destino deve ser a rota correta.
-		targetPath := "http://localhost:8082"
+	// Estado dos upstreams (health checks e circuit breakers) para monitoramento
+	statusRoute := RouteConfig{Name: "_gateway", Prefix: "/_gateway", Middlewares: table.StatusMiddlewares}
+	statusChain, err := routeChain(statusRoute, middlewares)
+	if err != nil {
+		return nil, err
+	}
+	statusChain = append([]gin.HandlerFunc{middleware.Adapt(middleware.CORS(defaultCORS))}, statusChain...)
+	router.GET("/_gateway/upstreams", append(statusChain, upstreamStatusHandler(pools))...)
+	log.Printf("🔀 Rota %s: /_gateway/upstreams middlewares: %s", statusRoute.Name, describeChain(statusRoute.Middlewares))
 
-		target, _ := url.Parse(targetPath)
-		proxy := httputil.NewSingleHostReverseProxy(target)
+	// Health check
+	// router.GET("/health", gin.HandlerFunc(func(ctx *gin.Context) {
+	// 	ctx.JSON(200, gin.H{"status": "Gateway service is healthy"})
+	// }))
 
-		proxy.ServeHTTP(ctx.Writer, ctx.Request)
-	}))
+	return router, nil
+}
 
-	router.GET("/clientes", gin.HandlerFunc(func(ctx *gin.Context) {
-		targetPath := `http://localhost:8082`
+// corsFromConfig monta a política CORS padrão a partir do app.env.
+func corsFromConfig(cfg util.Config) middleware.CORSConfig {
+	allowCredentials := cfg.CORSAllowCredentials
+	return middleware.CORSConfig{
+		AllowedOrigins:   cfg.CORSAllowedOrigins,
+		AllowedMethods:   cfg.CORSAllowedMethods,
+		AllowedHeaders:   cfg.CORSAllowedHeaders,
+		ExposedHeaders:   cfg.CORSExposedHeaders,
+		AllowCredentials: &allowCredentials,
+		MaxAge:           cfg.CORSMaxAge,
+	}
+}
 
-		target, _ := url.Parse(targetPath)
-		proxy := httputil.NewSingleHostReverseProxy(target)
+// routeChain resolve os middlewares declarados na rota, na ordem da tabela.
+func routeChain(route RouteConfig, middlewares Middlewares) ([]gin.HandlerFunc, error) {
+	chain := make([]gin.HandlerFunc, 0, len(route.Middlewares)+1)
+	for _, name := range route.Middlewares {
+		factory, ok := middlewares[name]
+		if !ok {
+			return nil, fmt.Errorf("route %q: unknown middleware %q", route.Name, name)
+		}
+		mw, err := factory(route)
+		if err != nil {
+			return nil, fmt.Errorf("route %q: middleware %q: %w", route.Name, name, err)
+		}
+		chain = append(chain, mw)
+	}
+	return chain, nil
+}
 
-		proxy.ServeHTTP(ctx.Writer, ctx.Request)
-	}))
+func describeChain(names []string) string {
+	if len(names) == 0 {
+		return "(pública)"
+	}
+	return strings.Join(names, " -> ")
+}
 
-	// mapping apenas p criacao de doc (ja que eh outra api)
-	router.Any("/docs/*path", gin.HandlerFunc(func(ctx *gin.Context) {
-		target, _ := url.Parse("http://localhost:8083")
-		proxy := httputil.NewSingleHostReverseProxy(target)
+func describeMethods(methods []string) string {
+	if len(methods) == 0 {
+		return "[*]"
+	}
+	return "[" + strings.Join(methods, ",") + "]"
+}
 
-		proxy.ServeHTTP(ctx.Writer, ctx.Request)
-	}))
+// methodNotAllowed responde 405 com os métodos aceitos pela rota em Allow.
+func methodNotAllowed(methods []string) gin.HandlerFunc {
+	allow := strings.Join(methods, ", ")
+	return func(c *gin.Context) {
+		c.Header("Allow", allow)
+		c.AbortWithStatusJSON(http.StatusMethodNotAllowed, gin.H{"error": http.StatusText(http.StatusMethodNotAllowed)})
+	}
+}
 
-	// Health check
-	// router.GET("/health", gin.HandlerFunc(func(ctx *gin.Context) {
-	// 	ctx.JSON(200, gin.H{"status": "Gateway service is healthy"})
-	// }))
+// newRouteHandler encaminha as requisições da rota para o pool de upstreams.
+func newRouteHandler(route RouteConfig, pool *proxy.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if route.StripPrefix {
+			stripPrefix(c.Request, route.Prefix)
+		}
+
+		// Cabeçalhos de identidade só podem vir do gateway: os enviados pelo
+		// cliente são descartados e, em rotas autenticadas com
+		// GATEWAY_IDENTITY_KEY, substituídos pelos assinados por signIdentity a
+		// partir do token verificado
+		identity.Strip(c.Request.Header)
+		if payload, ok := sharedmw.AuthPayload(c); ok {
+			c.Request = c.Request.WithContext(token.NewContext(c.Request.Context(), payload))
+		}
+
+		pool.Serve(c.Writer, c.Request, balancerKey(c, pool.Balancer()))
+	}
+}
+
+// signIdentity assina a identidade do usuário na requisição enviada ao
+// upstream. Roda depois de o proxy montar a URL de destino, para que a
+// assinatura cubra o caminho que o backend de fato recebe.
+func signIdentity(signer *identity.Signer) func(*http.Request) {
+	return func(r *http.Request) {
+		payload, ok := token.FromContext(r.Context())
+		if !ok {
+			return
+		}
+		signer.Inject(r, identity.Identity{
+			User:        payload.Username,
+			SessionID:   payload.SessionID,
+			TokenID:     payload.ID,
+			Roles:       payload.Roles,
+			Permissions: payload.Permissions,
+			Scopes:      payload.Scopes,
+		}, time.Now())
+	}
+}
+
+// balancerKey extrai a chave do hash consistente. Sem chave (por exemplo, uma
+// requisição anônima), o IP do cliente é usado para manter a afinidade.
+func balancerKey(c *gin.Context, balancer proxy.BalancerConfig) string {
+	if balancer.Strategy != proxy.StrategyConsistentHash {
+		return ""
+	}
+
+	var key string
+	switch balancer.HashBy {
+	case proxy.HashByHeader:
+		key = c.GetHeader(balancer.HashHeader)
+	case proxy.HashByUsername:
+		if payload, ok := sharedmw.AuthPayload(c); ok {
+			key = payload.Username
+		}
+	}
+
+	if key == "" {
+		key = c.ClientIP()
+	}
+	return key
+}
+
+func upstreamStatusHandler(pools []*proxy.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		routes := make(map[string][]proxy.UpstreamStatus, len(pools))
+		for _, pool := range pools {
+			routes[pool.Name()] = pool.Status()
+		}
+		c.JSON(http.StatusOK, gin.H{"routes": routes})
+	}
+}
+
+// stripPrefix remove o prefixo da rota antes de encaminhar a requisição.
+func stripPrefix(r *http.Request, prefix string) {
+	r.URL.Path = ensureLeadingSlash(strings.TrimPrefix(r.URL.Path, prefix))
+	if r.URL.RawPath != "" {
+		r.URL.RawPath = ensureLeadingSlash(strings.TrimPrefix(r.URL.RawPath, prefix))
+	}
+}
 
-	return router
+func ensureLeadingSlash(path string) string {
+	if !strings.HasPrefix(path, "/") {
+		return "/" + path
+	}
+	return path
 }
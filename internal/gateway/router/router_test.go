@@ -0,0 +1,222 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"api--sigacore-gateway/internal/gateway/proxy"
+	"api--sigacore-gateway/internal/shared/identity"
+	"api--sigacore-gateway/internal/token"
+	"api--sigacore-gateway/internal/util"
+)
+
+func TestStripPrefix(t *testing.T) {
+	testCases := []struct {
+		target      string
+		wantPath    string
+		wantRawPath string
+	}{
+		{"/users", "/", ""},
+		{"/users/", "/", ""},
+		{"/users/42", "/42", ""},
+		{"/users/42/sessions?page=2", "/42/sessions", ""},
+		{"/users/a%2Fb", "/a/b", "/a%2Fb"},
+	}
+
+	for _, tc := range testCases {
+		r := httptest.NewRequest(http.MethodGet, tc.target, nil)
+		stripPrefix(r, "/users")
+		require.Equal(t, tc.wantPath, r.URL.Path, tc.target)
+		require.Equal(t, tc.wantRawPath, r.URL.RawPath, tc.target)
+	}
+}
+
+func TestRouteHandlerStripPrefix(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.RequestURI()))
+	}))
+	t.Cleanup(upstream.Close)
+
+	engine := gin.New()
+	for _, route := range []RouteConfig{
+		{Name: "users", Prefix: "/users", StripPrefix: true},
+		{Name: "docs", Prefix: "/docs"},
+	} {
+		pool, err := proxy.NewPool(proxy.PoolConfig{Name: route.Name, Targets: []string{upstream.URL}})
+		require.NoError(t, err)
+		handler := newRouteHandler(route, pool)
+		// Os dois caminhos registrados por SetupGatewayRoutes
+		engine.Any(route.Prefix, handler)
+		engine.Any(route.Prefix+"/*path", handler)
+	}
+
+	testCases := []struct {
+		target string
+		want   string
+	}{
+		{"/users", "/"},
+		{"/users/42", "/42"},
+		{"/users/42/sessions?page=2", "/42/sessions?page=2"},
+		{"/docs", "/docs"},
+		{"/docs/1", "/docs/1"},
+	}
+
+	for _, tc := range testCases {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.target, nil))
+		require.Equal(t, http.StatusOK, w.Code, tc.target)
+		require.Equal(t, tc.want, w.Body.String(), tc.target)
+	}
+}
+
+func TestRouteHandlerIdentity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	signer, err := identity.NewSigner("test_identity_key_0123456789abcdef", time.Minute)
+	require.NoError(t, err)
+
+	// O backend confere a assinatura com o caminho que recebeu, já com o
+	// caminho base do upstream
+	var received identity.Identity
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := signer.Verify(r, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		received = id
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	t.Cleanup(upstream.Close)
+
+	route := RouteConfig{Name: "docs", Prefix: "/docs", StripPrefix: true}
+	pool, err := proxy.NewPool(proxy.PoolConfig{
+		Name:    route.Name,
+		Targets: []string{upstream.URL + "/api"},
+		Rewrite: signIdentity(signer),
+	})
+	require.NoError(t, err)
+
+	payload := &token.Payload{
+		ID:        uuid.New(),
+		Username:  "alice",
+		SessionID: uuid.New(),
+		Roles:     []string{"editor"},
+		Scopes:    []string{"docs.read"},
+	}
+	engine := gin.New()
+	engine.Any(route.Prefix+"/*path", func(c *gin.Context) {
+		// Faz o papel do middleware de autenticação
+		if c.GetHeader("Authorization") != "" {
+			c.Request = c.Request.WithContext(token.NewContext(c.Request.Context(), payload))
+		}
+	}, newRouteHandler(route, pool))
+
+	r := httptest.NewRequest(http.MethodGet, "/docs/42", nil)
+	r.Header.Set("Authorization", "Bearer test")
+	r.Header.Set(identity.HeaderRoles, "admin")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, "/api/42", w.Body.String())
+	require.Equal(t, identity.Identity{
+		User:      "alice",
+		SessionID: payload.SessionID,
+		TokenID:   payload.ID,
+		Roles:     []string{"editor"},
+		Scopes:    []string{"docs.read"},
+	}, received)
+
+	// Sem autenticação, os cabeçalhos forjados pelo cliente não chegam
+	r = httptest.NewRequest(http.MethodGet, "/docs/42", nil)
+	r.Header.Set(identity.HeaderUser, "mallory")
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, r)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Contains(t, w.Body.String(), identity.ErrMissingIdentity.Error())
+}
+
+func TestRouteOptionsFallback(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Method))
+	}))
+	t.Cleanup(upstream.Close)
+
+	cfg := util.Config{
+		CORSAllowedOrigins: []string{"https://app.example.com"},
+		CORSAllowedMethods: []string{http.MethodGet, http.MethodPost},
+	}
+	table := RouteTable{Routes: []RouteConfig{
+		{Name: "docs", Prefix: "/docs", Upstreams: []string{upstream.URL}, Methods: []string{"GET", "POST"}},
+		{Name: "files", Prefix: "/files", Upstreams: []string{upstream.URL}, Methods: []string{"GET", "OPTIONS"}},
+	}}
+	engine, err := SetupGatewayRoutes(t.Context(), cfg, table, Middlewares{})
+	require.NoError(t, err)
+
+	send := func(target string, preflight bool) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodOptions, target, nil)
+		if preflight {
+			r.Header.Set("Origin", "https://app.example.com")
+			r.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, r)
+		return w
+	}
+
+	// O preflight é respondido pelo CORS
+	w := send("/docs/1", true)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+
+	// Um OPTIONS comum não é aceito pela rota
+	for _, target := range []string{"/docs", "/docs/1"} {
+		w = send(target, false)
+		require.Equal(t, http.StatusMethodNotAllowed, w.Code, target)
+		require.Equal(t, "GET, POST", w.Header().Get("Allow"), target)
+	}
+
+	// Rota que declara OPTIONS encaminha ao upstream
+	w = send("/files/1", false)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, http.MethodOptions, w.Body.String())
+}
+
+func TestRouteChain(t *testing.T) {
+	var calls []string
+	middlewares := Middlewares{
+		"first": func(route RouteConfig) (gin.HandlerFunc, error) {
+			calls = append(calls, "first:"+route.Name)
+			return func(c *gin.Context) {}, nil
+		},
+		"second": func(route RouteConfig) (gin.HandlerFunc, error) {
+			calls = append(calls, "second:"+route.Name)
+			return func(c *gin.Context) {}, nil
+		},
+		"broken": func(RouteConfig) (gin.HandlerFunc, error) {
+			return nil, errors.New("missing key")
+		},
+	}
+
+	chain, err := routeChain(RouteConfig{Name: "users", Middlewares: []string{"second", "first"}}, middlewares)
+	require.NoError(t, err)
+	require.Len(t, chain, 2)
+	require.Equal(t, []string{"second:users", "first:users"}, calls)
+
+	_, err = routeChain(RouteConfig{Name: "users", Middlewares: []string{"first", "auht"}}, middlewares)
+	require.EqualError(t, err, `route "users": unknown middleware "auht"`)
+
+	_, err = routeChain(RouteConfig{Name: "users", Middlewares: []string{"broken"}}, middlewares)
+	require.EqualError(t, err, `route "users": middleware "broken": missing key`)
+}
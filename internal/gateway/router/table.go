@@ -0,0 +1,245 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"api--sigacore-gateway/internal/gateway/middleware"
+	"api--sigacore-gateway/internal/gateway/proxy"
+	"api--sigacore-gateway/internal/util"
+)
+
+// RouteTable descreve todas as rotas expostas pelo gateway.
+type RouteTable struct {
+	Routes []RouteConfig `yaml:"routes" json:"routes"`
+	// StatusMiddlewares protege o endpoint de monitoramento /_gateway/upstreams
+	StatusMiddlewares []string `yaml:"status_middlewares" json:"status_middlewares"`
+}
+
+// RouteConfig descreve uma rota do gateway e o(s) backend(s) que a atendem.
+type RouteConfig struct {
+	Name        string   `yaml:"name" json:"name"`
+	Prefix      string   `yaml:"prefix" json:"prefix"`
+	Upstreams   []string `yaml:"upstreams" json:"upstreams"`
+	StripPrefix bool     `yaml:"strip_prefix" json:"strip_prefix"`
+	Methods     []string `yaml:"methods" json:"methods"`
+	Middlewares []string `yaml:"middlewares" json:"middlewares"`
+
+	// Transport ajusta as conexões com cada upstream da rota
+	Transport proxy.TransportConfig `yaml:"transport" json:"transport"`
+	// Balancer e HealthCheck controlam como as réplicas da rota são usadas
+	Balancer    proxy.BalancerConfig    `yaml:"balancer" json:"balancer"`
+	HealthCheck proxy.HealthCheckConfig `yaml:"health_check" json:"health_check"`
+	// CircuitBreaker protege cada upstream da rota contra falhas em cascata
+	CircuitBreaker proxy.BreakerConfig `yaml:"circuit_breaker" json:"circuit_breaker"`
+	// Retry repete requisições idempotentes que falharam no upstream
+	Retry proxy.RetryConfig `yaml:"retry" json:"retry"`
+
+	// Parâmetros dos middlewares da rota; valores vazios usam os padrões do app.env
+	RateLimit RateLimitConfig `yaml:"rate_limit" json:"rate_limit"`
+	// AllowedIPs e DeniedIPs aceitam IPs e blocos CIDR (IPv4 ou IPv6); com
+	// DeniedIPs a rota usa o modo denylist
+	AllowedIPs []string `yaml:"allowed_ips" json:"allowed_ips"`
+	DeniedIPs  []string `yaml:"denied_ips" json:"denied_ips"`
+	// CORS substitui, campo a campo, a política CORS_* do app.env
+	CORS *middleware.CORSConfig `yaml:"cors" json:"cors"`
+	// TokenExchange configura o middleware token_exchange da rota
+	TokenExchange TokenExchangeConfig `yaml:"token_exchange" json:"token_exchange"`
+	// Roles e Permissions são exigidos pelos middlewares require_role (algum
+	// dos papéis) e require_permission (todas as permissões)
+	Roles       []string `yaml:"roles" json:"roles"`
+	Permissions []string `yaml:"permissions" json:"permissions"`
+	// Scopes são os escopos OAuth2 exigidos pelo middleware require_scope
+	Scopes []string `yaml:"scopes" json:"scopes"`
+}
+
+// TokenExchangeConfig define o token interno emitido para o backend da rota.
+type TokenExchangeConfig struct {
+	// Audience é o nome do serviço de destino (padrão: nome da rota)
+	Audience string        `yaml:"audience" json:"audience"`
+	Duration time.Duration `yaml:"duration" json:"duration"`
+}
+
+// RateLimitConfig configura o middleware rate_limit de uma rota.
+type RateLimitConfig struct {
+	RequestsPerSecond float64 `yaml:"requests_per_second" json:"requests_per_second"`
+	Burst             int     `yaml:"burst" json:"burst"`
+}
+
+// LoadRouteTable lê a tabela de rotas de um arquivo YAML ou JSON.
+// Referências como ${USER_SERVICE_ADDRESS} nos upstreams são resolvidas
+// a partir da configuração carregada (ou, em último caso, do ambiente).
+func LoadRouteTable(path string, cfg util.Config) (RouteTable, error) {
+	var table RouteTable
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return table, fmt.Errorf("LoadRouteTable: %w", err)
+	}
+
+	// JSON é um subconjunto de YAML, então o mesmo decoder atende os dois formatos
+	if err := yaml.Unmarshal(data, &table); err != nil {
+		return table, fmt.Errorf("LoadRouteTable: %s: %w", path, err)
+	}
+
+	lookup := configLookup(cfg)
+	for i := range table.Routes {
+		route := &table.Routes[i]
+		for j, upstream := range route.Upstreams {
+			route.Upstreams[j] = os.Expand(upstream, lookup)
+		}
+	}
+
+	if err := table.Validate(); err != nil {
+		return table, fmt.Errorf("LoadRouteTable: %s: %w", path, err)
+	}
+
+	return table, nil
+}
+
+// Validate normaliza e valida as rotas da tabela.
+func (t *RouteTable) Validate() error {
+	if len(t.Routes) == 0 {
+		return fmt.Errorf("route table has no routes")
+	}
+
+	seen := make(map[string]string)
+	for i := range t.Routes {
+		route := &t.Routes[i]
+		if route.Name == "" {
+			route.Name = route.Prefix
+		}
+
+		if !strings.HasPrefix(route.Prefix, "/") {
+			return fmt.Errorf("route %q: prefix must start with '/'", route.Name)
+		}
+		if strings.ContainsAny(route.Prefix, ":*") {
+			return fmt.Errorf("route %q: prefix must not contain path parameters", route.Name)
+		}
+		route.Prefix = strings.TrimSuffix(route.Prefix, "/")
+		if route.Prefix == "" {
+			return fmt.Errorf("route %q: prefix '/' is not allowed", route.Name)
+		}
+
+		if other, ok := seen[route.Prefix]; ok {
+			return fmt.Errorf("route %q: prefix %s already used by route %q", route.Name, route.Prefix, other)
+		}
+		// Cada rota registra prefix/*path, e o gin não aceita outra rota
+		// abaixo desse curinga (entraria em pânico ao montar o engine)
+		for prefix, other := range seen {
+			if strings.HasPrefix(route.Prefix, prefix+"/") || strings.HasPrefix(prefix, route.Prefix+"/") {
+				return fmt.Errorf("route %q: prefix %s overlaps prefix %s of route %q",
+					route.Name, route.Prefix, prefix, other)
+			}
+		}
+		seen[route.Prefix] = route.Name
+
+		if len(route.Upstreams) == 0 {
+			return fmt.Errorf("route %q: at least one upstream is required", route.Name)
+		}
+		for j, upstream := range route.Upstreams {
+			normalized, err := normalizeUpstream(upstream)
+			if err != nil {
+				return fmt.Errorf("route %q: %w", route.Name, err)
+			}
+			route.Upstreams[j] = normalized
+		}
+
+		for j, method := range route.Methods {
+			method = strings.ToUpper(strings.TrimSpace(method))
+			if !isValidMethod(method) {
+				return fmt.Errorf("route %q: unsupported method %q", route.Name, method)
+			}
+			route.Methods[j] = method
+		}
+
+		if len(route.AllowedIPs) > 0 && len(route.DeniedIPs) > 0 {
+			return fmt.Errorf("route %q: allowed_ips and denied_ips are mutually exclusive", route.Name)
+		}
+		if _, err := util.ParseNetworks(route.AllowedIPs); err != nil {
+			return fmt.Errorf("route %q: allowed_ips: %w", route.Name, err)
+		}
+		if _, err := util.ParseNetworks(route.DeniedIPs); err != nil {
+			return fmt.Errorf("route %q: denied_ips: %w", route.Name, err)
+		}
+
+		if slices.Contains(route.Middlewares, "require_role") && len(route.Roles) == 0 {
+			return fmt.Errorf("route %q: require_role needs roles", route.Name)
+		}
+		if slices.Contains(route.Middlewares, "require_permission") && len(route.Permissions) == 0 {
+			return fmt.Errorf("route %q: require_permission needs permissions", route.Name)
+		}
+		if slices.Contains(route.Middlewares, "require_scope") && len(route.Scopes) == 0 {
+			return fmt.Errorf("route %q: require_scope needs scopes", route.Name)
+		}
+
+		if route.CORS != nil {
+			for _, origin := range route.CORS.AllowedOrigins {
+				if origin != "*" && strings.Count(origin, "*") > 1 {
+					return fmt.Errorf("route %q: cors: origin %q has more than one wildcard", route.Name, origin)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// normalizeUpstream garante que o upstream seja uma URL absoluta. Endereços no
+// formato host:porta (como AUTH_SERVER_ADDRESS) recebem o esquema http.
+func normalizeUpstream(upstream string) (string, error) {
+	upstream = strings.TrimSpace(upstream)
+	if upstream == "" {
+		return "", fmt.Errorf("empty upstream")
+	}
+	if !strings.Contains(upstream, "://") {
+		upstream = "http://" + upstream
+	}
+
+	target, err := url.Parse(upstream)
+	if err != nil {
+		return "", fmt.Errorf("invalid upstream %q: %w", upstream, err)
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return "", fmt.Errorf("invalid upstream %q: scheme must be http or https", upstream)
+	}
+	if target.Host == "" {
+		return "", fmt.Errorf("invalid upstream %q: missing host", upstream)
+	}
+
+	return upstream, nil
+}
+
+func isValidMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// configLookup resolve variáveis usadas na tabela de rotas a partir da configuração.
+func configLookup(cfg util.Config) func(string) string {
+	addresses := map[string]string{
+		"AUTH_SERVER_ADDRESS":          cfg.AuthServerAddress,
+		"GATEWAY_SERVER_ADDRESS":       cfg.GatewayServerAddress,
+		"USER_SERVICE_ADDRESS":         cfg.UserServiceAddress,
+		"DOC_SERVICE_ADDRESS":          cfg.DocServiceAddress,
+		"NOTIFICATION_SERVICE_ADDRESS": cfg.NotificationServiceAddress,
+	}
+
+	return func(key string) string {
+		if addr, ok := addresses[key]; ok {
+			return addr
+		}
+		return os.Getenv(key)
+	}
+}
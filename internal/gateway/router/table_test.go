@@ -0,0 +1,186 @@
+package router
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"api--sigacore-gateway/internal/util"
+)
+
+func writeRouteTable(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadRouteTable(t *testing.T) {
+	t.Setenv("REPORTS_SERVICE_ADDRESS", "https://reports.internal:9443")
+	t.Setenv("USER_SERVICE_ADDRESS", "http://from-env:1")
+	cfg := util.Config{UserServiceAddress: "localhost:8082", DocServiceAddress: "http://docs:8083"}
+
+	path := writeRouteTable(t, "routes.yaml", `
+routes:
+  - name: users
+    prefix: /users/
+    upstreams: ["${USER_SERVICE_ADDRESS}"]
+    methods: [get, " post "]
+  - prefix: /docs
+    upstreams: ["${DOC_SERVICE_ADDRESS}", "docs-2:8083"]
+  - name: reports
+    prefix: /reports
+    upstreams: ["${REPORTS_SERVICE_ADDRESS}/api"]
+status_middlewares: [auth]
+`)
+	table, err := LoadRouteTable(path, cfg)
+	require.NoError(t, err)
+	require.Len(t, table.Routes, 3)
+	require.Equal(t, []string{"auth"}, table.StatusMiddlewares)
+
+	// A configuração tem precedência sobre o ambiente; sem esquema, vira http
+	users := table.Routes[0]
+	require.Equal(t, "/users", users.Prefix)
+	require.Equal(t, []string{"http://localhost:8082"}, users.Upstreams)
+	require.Equal(t, []string{"GET", "POST"}, users.Methods)
+
+	docs := table.Routes[1]
+	require.Equal(t, "/docs", docs.Name)
+	require.Equal(t, []string{"http://docs:8083", "http://docs-2:8083"}, docs.Upstreams)
+
+	// Variáveis fora da configuração vêm do ambiente
+	require.Equal(t, []string{"https://reports.internal:9443/api"}, table.Routes[2].Upstreams)
+
+	// JSON também é aceito
+	path = writeRouteTable(t, "routes.json",
+		`{"routes": [{"name": "users", "prefix": "/users", "upstreams": ["${USER_SERVICE_ADDRESS}"]}]}`)
+	table, err = LoadRouteTable(path, cfg)
+	require.NoError(t, err)
+	require.Equal(t, []string{"http://localhost:8082"}, table.Routes[0].Upstreams)
+
+	// Variável sem valor deixa o upstream vazio, o que é recusado
+	path = writeRouteTable(t, "missing.yaml", `
+routes:
+  - prefix: /users
+    upstreams: ["${UNDEFINED_SERVICE_ADDRESS}"]
+`)
+	_, err = LoadRouteTable(path, cfg)
+	require.ErrorContains(t, err, "empty upstream")
+
+	_, err = LoadRouteTable(filepath.Join(t.TempDir(), "absent.yaml"), cfg)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestRouteTableValidate(t *testing.T) {
+	upstream := []string{"http://localhost:8082"}
+
+	testCases := []struct {
+		name    string
+		routes  []RouteConfig
+		wantErr string
+	}{
+		{
+			name:    "no routes",
+			wantErr: "no routes",
+		},
+		{
+			name: "duplicate prefix",
+			routes: []RouteConfig{
+				{Name: "a", Prefix: "/users", Upstreams: upstream},
+				{Name: "b", Prefix: "/users/", Upstreams: upstream},
+			},
+			wantErr: `prefix /users already used by route "a"`,
+		},
+		{
+			name: "nested prefix",
+			routes: []RouteConfig{
+				{Name: "a", Prefix: "/users", Upstreams: upstream},
+				{Name: "b", Prefix: "/users/admin", Upstreams: upstream},
+			},
+			wantErr: `prefix /users/admin overlaps prefix /users of route "a"`,
+		},
+		{
+			name: "parent prefix after child",
+			routes: []RouteConfig{
+				{Name: "a", Prefix: "/api/v1/docs", Upstreams: upstream},
+				{Name: "b", Prefix: "/api", Upstreams: upstream},
+			},
+			wantErr: `prefix /api overlaps prefix /api/v1/docs of route "a"`,
+		},
+		{
+			name:    "root prefix",
+			routes:  []RouteConfig{{Name: "a", Prefix: "/", Upstreams: upstream}},
+			wantErr: "prefix '/' is not allowed",
+		},
+		{
+			name:    "relative prefix",
+			routes:  []RouteConfig{{Name: "a", Prefix: "users", Upstreams: upstream}},
+			wantErr: "must start with '/'",
+		},
+		{
+			name:    "path parameter",
+			routes:  []RouteConfig{{Name: "a", Prefix: "/users/:id", Upstreams: upstream}},
+			wantErr: "must not contain path parameters",
+		},
+		{
+			name:    "no upstreams",
+			routes:  []RouteConfig{{Name: "a", Prefix: "/users"}},
+			wantErr: "at least one upstream",
+		},
+		{
+			name:    "upstream scheme",
+			routes:  []RouteConfig{{Name: "a", Prefix: "/users", Upstreams: []string{"ftp://files:21"}}},
+			wantErr: "scheme must be http or https",
+		},
+		{
+			name:    "upstream without host",
+			routes:  []RouteConfig{{Name: "a", Prefix: "/users", Upstreams: []string{"http://"}}},
+			wantErr: "missing host",
+		},
+		{
+			name:    "malformed upstream",
+			routes:  []RouteConfig{{Name: "a", Prefix: "/users", Upstreams: []string{"http://bad host:80"}}},
+			wantErr: "invalid upstream",
+		},
+		{
+			name:    "unknown method",
+			routes:  []RouteConfig{{Name: "a", Prefix: "/users", Upstreams: upstream, Methods: []string{"FETCH"}}},
+			wantErr: `unsupported method "FETCH"`,
+		},
+		{
+			name: "allowed and denied ips",
+			routes: []RouteConfig{{Name: "a", Prefix: "/users", Upstreams: upstream,
+				AllowedIPs: []string{"10.0.0.0/8"}, DeniedIPs: []string{"10.0.0.1"}}},
+			wantErr: "mutually exclusive",
+		},
+		{
+			name: "require_role without roles",
+			routes: []RouteConfig{{Name: "a", Prefix: "/users", Upstreams: upstream,
+				Middlewares: []string{"auth", "require_role"}}},
+			wantErr: "require_role needs roles",
+		},
+		{
+			name: "valid siblings",
+			routes: []RouteConfig{
+				{Name: "a", Prefix: "/users", Upstreams: upstream},
+				{Name: "b", Prefix: "/users-admin", Upstreams: upstream},
+				{Name: "c", Prefix: "/api/v1/users", Upstreams: upstream},
+				{Name: "d", Prefix: "/api/v2/users", Upstreams: upstream},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			table := RouteTable{Routes: tc.routes}
+			err := table.Validate()
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
@@ -1,11 +1,19 @@
 package gateway
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"slices"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
 
+	db "api--sigacore-gateway/internal/db/sqlc"
+	"api--sigacore-gateway/internal/gateway/middleware"
 	"api--sigacore-gateway/internal/gateway/router"
+	sharedmw "api--sigacore-gateway/internal/shared/middleware"
+	"api--sigacore-gateway/internal/shared/revocation"
 	"api--sigacore-gateway/internal/token"
 	"api--sigacore-gateway/internal/util"
 )
@@ -13,29 +21,181 @@ import (
 type GatewayServer struct {
 	config     util.Config
 	router     *gin.Engine
+	routes     router.RouteTable
 	tokenMaker token.Maker
 }
 
-func NewGatewayServer(cfg util.Config) (*GatewayServer, error) {
-	tokenMaker, err := token.NewPasetoMaker(cfg.TokenSymmetricKey)
+// NewGatewayServer monta o gateway. As tarefas em segundo plano (como os health
+// checks dos upstreams) seguem o ciclo de vida de ctx. store guarda a lista
+// de tokens revogados e, com RATE_LIMIT_BACKEND=postgres, os rate limiters.
+func NewGatewayServer(ctx context.Context, cfg util.Config, store db.Store) (*GatewayServer, error) {
+	tokenMaker, err := token.NewMakerFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	revocations := revocation.NewList(store, cfg.TokenRevocationRefresh)
+	revocations.Start(ctx)
+	tokenMaker = token.NewRevocationMaker(tokenMaker, revocations)
+
+	routes, err := router.LoadRouteTable(cfg.GatewayRoutesFile, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := router.SetupGatewayRoutes(ctx, cfg, routes, gatewayMiddlewares(cfg, tokenMaker, newLimiterStore(ctx, cfg, store)))
 	if err != nil {
 		return nil, err
 	}
 
 	return &GatewayServer{
 		config:     cfg,
-		router:     router.SetupGatewayRoutes(cfg),
+		router:     r,
+		routes:     routes,
 		tokenMaker: tokenMaker,
 	}, nil
 }
 
+// newLimiterStore escolhe onde fica o estado dos rate limiters. Com mais de uma
+// réplica do gateway, só o backend postgres mantém o limite global.
+func newLimiterStore(ctx context.Context, cfg util.Config, store db.Store) middleware.LimiterStore {
+	if cfg.RateLimitBackend == util.RateLimitBackendPostgres {
+		limiterStore := middleware.NewPostgresLimiterStore(store)
+		limiterStore.Start(ctx)
+		return limiterStore
+	}
+
+	limiterStore := middleware.NewMemoryLimiterStore()
+	limiterStore.Start(ctx)
+	return limiterStore
+}
+
+// gatewayMiddlewares registra os middlewares que as rotas podem declarar na
+// tabela de rotas.
+func gatewayMiddlewares(cfg util.Config, tokenMaker token.Maker, limiterStore middleware.LimiterStore) router.Middlewares {
+	// Limites por cliente valem para o gateway inteiro, não por rota
+	clientLimiter := middleware.NewRateLimiter(limiterStore, rate.Limit(cfg.RateLimitPerIP), rate.Limit(cfg.RateLimitPerUser))
+
+	return router.Middlewares{
+		"auth": func(router.RouteConfig) (gin.HandlerFunc, error) {
+			return middleware.Adapt(middleware.AuthMiddleware(tokenMaker)), nil
+		},
+		"rate_limit": func(route router.RouteConfig) (gin.HandlerFunc, error) {
+			rps, burst := cfg.RateLimitPerSecond, cfg.RateLimitBurst
+			if route.RateLimit.RequestsPerSecond > 0 {
+				rps = route.RateLimit.RequestsPerSecond
+			}
+			if route.RateLimit.Burst > 0 {
+				burst = route.RateLimit.Burst
+			}
+			limit := middleware.Limit{Rate: rate.Limit(rps), Burst: burst}
+			return middleware.Adapt(middleware.StoreRateLimiter(limiterStore, "route:"+route.Name, limit)), nil
+		},
+		"client_rate_limit": func(router.RouteConfig) (gin.HandlerFunc, error) {
+			return clientLimiter.Middleware(), nil
+		},
+		"ip_whitelist": func(route router.RouteConfig) (gin.HandlerFunc, error) {
+			return ipFilterMiddleware(cfg, route)
+		},
+		"token_exchange": func(route router.RouteConfig) (gin.HandlerFunc, error) {
+			return tokenExchangeMiddleware(cfg, route)
+		},
+		"require_role": func(route router.RouteConfig) (gin.HandlerFunc, error) {
+			if err := requireAfterAuth(route, "require_role"); err != nil {
+				return nil, err
+			}
+			return sharedmw.RequireRole(route.Roles...), nil
+		},
+		"require_permission": func(route router.RouteConfig) (gin.HandlerFunc, error) {
+			if err := requireAfterAuth(route, "require_permission"); err != nil {
+				return nil, err
+			}
+			return sharedmw.RequirePermission(route.Permissions...), nil
+		},
+		"require_scope": func(route router.RouteConfig) (gin.HandlerFunc, error) {
+			if err := requireAfterAuth(route, "require_scope"); err != nil {
+				return nil, err
+			}
+			return sharedmw.RequireScope(route.Scopes...), nil
+		},
+	}
+}
+
+// requireAfterAuth garante que o middleware name venha depois de auth, que é
+// quem coloca o payload do token no contexto.
+func requireAfterAuth(route router.RouteConfig, name string) error {
+	authAt, at := slices.Index(route.Middlewares, "auth"), slices.Index(route.Middlewares, name)
+	if authAt < 0 || authAt > at {
+		return fmt.Errorf("%s must come after auth", name)
+	}
+	return nil
+}
+
+// ipFilterMiddleware monta o filtro de IPs de uma rota. Listas declaradas na
+// rota substituem ALLOWED_IPS/DENIED_IPS e definem o modo do filtro.
+func ipFilterMiddleware(cfg util.Config, route router.RouteConfig) (gin.HandlerFunc, error) {
+	trusted, err := util.ParseNetworks(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	mode, entries := cfg.IPFilterMode, cfg.AllowedIPs
+	if mode == util.IPFilterDenylist {
+		entries = cfg.DeniedIPs
+	}
+	if len(route.AllowedIPs) > 0 {
+		mode, entries = util.IPFilterAllowlist, route.AllowedIPs
+	} else if len(route.DeniedIPs) > 0 {
+		mode, entries = util.IPFilterDenylist, route.DeniedIPs
+	}
+
+	networks, err := util.ParseNetworks(entries)
+	if err != nil {
+		return nil, err
+	}
+	if mode == util.IPFilterDenylist {
+		return middleware.Adapt(middleware.IPDenylist(networks, trusted)), nil
+	}
+	return middleware.Adapt(middleware.IPWhitelist(networks, trusted)), nil
+}
+
+// tokenExchangeMiddleware monta a troca de token de uma rota, com um
+// InternalMaker para o serviço de destino.
+func tokenExchangeMiddleware(cfg util.Config, route router.RouteConfig) (gin.HandlerFunc, error) {
+	if err := requireAfterAuth(route, "token_exchange"); err != nil {
+		return nil, err
+	}
+	if cfg.InternalTokenSymmetricKey == "" {
+		return nil, fmt.Errorf("INTERNAL_TOKEN_SYMMETRIC_KEY is required")
+	}
+
+	audience := route.TokenExchange.Audience
+	if audience == "" {
+		audience = route.Name
+	}
+	duration := cfg.InternalTokenDuration
+	if route.TokenExchange.Duration > 0 {
+		duration = route.TokenExchange.Duration
+	}
+
+	maker, err := token.NewInternalMaker(cfg.InternalTokenSymmetricKey, audience)
+	if err != nil {
+		return nil, err
+	}
+	return middleware.TokenExchange(maker, duration), nil
+}
+
 func (s *GatewayServer) Start() error {
 	log.Printf("🚀 Gateway configurado com sucesso!")
-	log.Printf("📍 Serviços configurados:")
-	log.Printf("   - Auth Service: %s", s.config.AuthServerAddress)
-	log.Printf("   - User Service: %s", s.config.UserServiceAddress)
-	log.Printf("   - Report Service: %s", s.config.DocServiceAddress)
-	log.Printf("🔒 IPs permitidos: %v", s.config.AllowedIPs)
+	log.Printf("📍 Rotas configuradas (%s):", s.config.GatewayRoutesFile)
+	for _, route := range s.routes.Routes {
+		log.Printf("   - %s: %s -> %v %v", route.Name, route.Prefix, route.Upstreams, route.Middlewares)
+	}
+	if s.config.IPFilterMode == util.IPFilterDenylist {
+		log.Printf("🔒 IPs bloqueados: %v", s.config.DeniedIPs)
+	} else {
+		log.Printf("🔒 IPs permitidos: %v", s.config.AllowedIPs)
+	}
+	log.Printf("🔁 Proxies confiáveis: %v", s.config.TrustedProxies)
 
 	return s.router.Run(s.config.GatewayServerAddress)
 }
@@ -0,0 +1,187 @@
+// Package identity propaga a identidade do usuário do gateway para os
+// backends. O gateway verifica o token e envia a identidade em cabeçalhos
+// assinados com HMAC-SHA256, então os backends confiam no gateway sem
+// precisar da TOKEN_SYMMETRIC_KEY, apenas da GATEWAY_IDENTITY_KEY.
+//
+// A assinatura cobre o método e o caminho da requisição entregue ao backend,
+// então não vale para outra rota. Dentro de GATEWAY_IDENTITY_MAX_AGE, porém,
+// quem capturar os cabeçalhos (dentro da rede entre gateway e backends) pode
+// repetir a mesma requisição: a query string e o corpo não são assinados.
+package identity
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cabeçalhos de identidade. Qualquer valor enviado pelo cliente é descartado
+// pelo gateway antes do proxy.
+const (
+	HeaderUser      = "X-User"
+	HeaderSessionID = "X-Session-Id"
+	HeaderTokenID   = "X-Token-Id"
+	// Papéis, permissões e escopos vão separados por vírgula
+	HeaderRoles       = "X-User-Roles"
+	HeaderPermissions = "X-User-Permissions"
+	HeaderScopes      = "X-User-Scopes"
+	HeaderTimestamp   = "X-Identity-Timestamp"
+	HeaderSignature   = "X-Identity-Signature"
+)
+
+// MinKeySize é o tamanho mínimo da chave HMAC.
+const MinKeySize = 32
+
+// DefaultMaxAge é por quanto tempo uma assinatura é aceita.
+const DefaultMaxAge = 5 * time.Minute
+
+var (
+	ErrMissingIdentity  = errors.New("identity headers are missing")
+	ErrInvalidSignature = errors.New("identity signature is invalid")
+	ErrExpiredSignature = errors.New("identity signature has expired")
+)
+
+var _headers = []string{
+	HeaderUser, HeaderSessionID, HeaderTokenID, HeaderRoles, HeaderPermissions, HeaderScopes,
+	HeaderTimestamp, HeaderSignature,
+}
+
+// Identity é o usuário autenticado pelo gateway, com o que o token dele
+// concede: os backends aplicam require_role, require_permission e
+// require_scope sobre esses campos.
+type Identity struct {
+	User        string
+	SessionID   uuid.UUID
+	TokenID     uuid.UUID
+	Roles       []string
+	Permissions []string
+	Scopes      []string
+}
+
+// Signer assina (no gateway) e verifica (nos backends) os cabeçalhos de identidade.
+type Signer struct {
+	key    []byte
+	maxAge time.Duration
+}
+
+// NewSigner cria um Signer com a chave compartilhada entre gateway e backends.
+func NewSigner(key string, maxAge time.Duration) (*Signer, error) {
+	if len(key) < MinKeySize {
+		return nil, fmt.Errorf("NewSigner: key must have at least %d characters", MinKeySize)
+	}
+	if maxAge <= 0 {
+		maxAge = DefaultMaxAge
+	}
+	return &Signer{key: []byte(key), maxAge: maxAge}, nil
+}
+
+// Strip remove todos os cabeçalhos de identidade de h.
+func Strip(h http.Header) {
+	for _, name := range _headers {
+		h.Del(name)
+	}
+}
+
+// Inject substitui os cabeçalhos de identidade de r pela identidade assinada.
+// Deve ser chamado com o método e o caminho que o backend vai receber.
+func (s *Signer) Inject(r *http.Request, id Identity, now time.Time) {
+	h := r.Header
+	Strip(h)
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	h.Set(HeaderUser, id.User)
+	if id.SessionID != uuid.Nil {
+		h.Set(HeaderSessionID, id.SessionID.String())
+	}
+	h.Set(HeaderTokenID, id.TokenID.String())
+	setList(h, HeaderRoles, id.Roles)
+	setList(h, HeaderPermissions, id.Permissions)
+	setList(h, HeaderScopes, id.Scopes)
+	h.Set(HeaderTimestamp, timestamp)
+	h.Set(HeaderSignature, s.sign(r, id, timestamp))
+}
+
+// Verify confere a assinatura dos cabeçalhos de identidade de r, inclusive o
+// método e o caminho, e retorna a identidade que eles carregam.
+func (s *Signer) Verify(r *http.Request, now time.Time) (Identity, error) {
+	h := r.Header
+	user, timestamp, signature := h.Get(HeaderUser), h.Get(HeaderTimestamp), h.Get(HeaderSignature)
+	if user == "" || timestamp == "" || signature == "" {
+		return Identity{}, ErrMissingIdentity
+	}
+
+	id := Identity{
+		User:        user,
+		Roles:       getList(h, HeaderRoles),
+		Permissions: getList(h, HeaderPermissions),
+		Scopes:      getList(h, HeaderScopes),
+	}
+	var err error
+	if value := h.Get(HeaderSessionID); value != "" {
+		if id.SessionID, err = uuid.Parse(value); err != nil {
+			return Identity{}, ErrInvalidSignature
+		}
+	}
+	if id.TokenID, err = uuid.Parse(h.Get(HeaderTokenID)); err != nil {
+		return Identity{}, ErrInvalidSignature
+	}
+
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, s.mac(r, id, timestamp)) {
+		return Identity{}, ErrInvalidSignature
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return Identity{}, ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > s.maxAge || age < -s.maxAge {
+		return Identity{}, ErrExpiredSignature
+	}
+
+	return id, nil
+}
+
+func (s *Signer) sign(r *http.Request, id Identity, timestamp string) string {
+	return hex.EncodeToString(s.mac(r, id, timestamp))
+}
+
+// mac assina os campos separados por quebra de linha, que não pode aparecer em
+// valores de cabeçalho nem no caminho ainda codificado (como vai na linha da
+// requisição).
+func (s *Signer) mac(r *http.Request, id Identity, timestamp string) []byte {
+	sessionID := ""
+	if id.SessionID != uuid.Nil {
+		sessionID = id.SessionID.String()
+	}
+
+	m := hmac.New(sha256.New, s.key)
+	m.Write([]byte(strings.Join([]string{
+		"v2", r.Method, r.URL.EscapedPath(), id.User, sessionID, id.TokenID.String(),
+		strings.Join(id.Roles, ","), strings.Join(id.Permissions, ","), strings.Join(id.Scopes, ","),
+		timestamp,
+	}, "\n")))
+	return m.Sum(nil)
+}
+
+func setList(h http.Header, name string, values []string) {
+	if len(values) > 0 {
+		h.Set(name, strings.Join(values, ","))
+	}
+}
+
+func getList(h http.Header, name string) []string {
+	value := h.Get(name)
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
@@ -0,0 +1,123 @@
+package identity
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSigner(t *testing.T) *Signer {
+	signer, err := NewSigner("0123456789abcdefghijklmnopqrstuv", time.Minute)
+	require.NoError(t, err)
+	return signer
+}
+
+// resend copia os cabeçalhos de r para uma nova requisição com outro método
+// e alvo, como faria quem reaproveita cabeçalhos capturados.
+func resend(r *http.Request, method, target string) *http.Request {
+	other := httptest.NewRequest(method, target, nil)
+	other.Header = r.Header.Clone()
+	return other
+}
+
+func TestSignerRoundTrip(t *testing.T) {
+	signer := newTestSigner(t)
+	id := Identity{
+		User:        "alice",
+		SessionID:   uuid.New(),
+		TokenID:     uuid.New(),
+		Roles:       []string{"admin", "editor"},
+		Permissions: []string{"docs:read", "docs:write"},
+		Scopes:      []string{"docs.read"},
+	}
+	now := time.Now()
+
+	r := httptest.NewRequest(http.MethodPost, "/docs/42?draft=1", nil)
+	r.Header.Set(HeaderUser, "mallory")
+	r.Header.Set(HeaderRoles, "root")
+	signer.Inject(r, id, now)
+	require.Equal(t, "admin,editor", r.Header.Get(HeaderRoles))
+
+	got, err := signer.Verify(r, now.Add(30*time.Second))
+	require.NoError(t, err)
+	require.Equal(t, id, got)
+
+	// A query string não é assinada
+	got, err = signer.Verify(resend(r, http.MethodPost, "/docs/42"), now)
+	require.NoError(t, err)
+	require.Equal(t, id, got)
+
+	// Sem sessão, papéis nem escopos, os cabeçalhos não são enviados e a
+	// assinatura continua válida
+	id = Identity{User: "alice", TokenID: uuid.New()}
+	signer.Inject(r, id, now)
+	require.Empty(t, r.Header.Get(HeaderSessionID))
+	require.Empty(t, r.Header.Get(HeaderRoles))
+	require.Empty(t, r.Header.Get(HeaderScopes))
+	got, err = signer.Verify(r, now)
+	require.NoError(t, err)
+	require.Equal(t, id, got)
+}
+
+func TestSignerRejects(t *testing.T) {
+	signer := newTestSigner(t)
+	id := Identity{
+		User:      "alice",
+		SessionID: uuid.New(),
+		TokenID:   uuid.New(),
+		Roles:     []string{"editor"},
+		Scopes:    []string{"docs.read"},
+	}
+	now := time.Now()
+
+	signed := func() *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/docs/42", nil)
+		signer.Inject(r, id, now)
+		return r
+	}
+
+	_, err := signer.Verify(httptest.NewRequest(http.MethodGet, "/docs/42", nil), now)
+	require.ErrorIs(t, err, ErrMissingIdentity)
+
+	for name, value := range map[string]string{
+		HeaderUser:        "mallory",
+		HeaderSessionID:   uuid.NewString(),
+		HeaderRoles:       "editor,admin",
+		HeaderPermissions: "*",
+		HeaderScopes:      "docs.read,docs.write",
+	} {
+		r := signed()
+		r.Header.Set(name, value)
+		_, err = signer.Verify(r, now)
+		require.ErrorIs(t, err, ErrInvalidSignature, name)
+	}
+
+	// A assinatura não vale para outro método nem outro caminho
+	_, err = signer.Verify(resend(signed(), http.MethodDelete, "/docs/42"), now)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+	_, err = signer.Verify(resend(signed(), http.MethodGet, "/docs/43"), now)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+	_, err = signer.Verify(resend(signed(), http.MethodGet, "/docs%2F42"), now)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	_, err = signer.Verify(signed(), now.Add(2*time.Minute))
+	require.ErrorIs(t, err, ErrExpiredSignature)
+
+	other, err := NewSigner("vutsrqponmlkjihgfedcba9876543210", time.Minute)
+	require.NoError(t, err)
+	_, err = other.Verify(signed(), now)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	r := signed()
+	Strip(r.Header)
+	require.Empty(t, r.Header)
+}
+
+func TestNewSignerKeySize(t *testing.T) {
+	_, err := NewSigner("short", time.Minute)
+	require.Error(t, err)
+}
@@ -54,3 +54,14 @@ func AuthMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// AuthPayload retorna o payload do token verificado pelo AuthMiddleware, se
+// houver. Também reconhece o payload guardado no contexto da requisição pelo
+// AuthMiddleware net/http do gateway.
+func AuthPayload(c *gin.Context) (*token.Payload, bool) {
+	if value, ok := c.Get(_authPayloadKey); ok {
+		payload, ok := value.(*token.Payload)
+		return payload, ok
+	}
+	return token.FromContext(c.Request.Context())
+}
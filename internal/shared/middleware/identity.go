@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"api--sigacore-gateway/internal/shared/identity"
+	"api--sigacore-gateway/internal/token"
+)
+
+// GatewayIdentity autentica requisições vindas do gateway pelos cabeçalhos de
+// identidade assinados, sem verificar o token do usuário de novo. O payload
+// resultante (ID, Username, SessionID, papéis, permissões e escopos) fica
+// disponível em AuthPayload, então RequireRole, RequirePermission e
+// RequireScope funcionam depois dele. A assinatura só vale para o método e o
+// caminho da requisição; veja o pacote identity sobre repetição.
+func GatewayIdentity(signer *identity.Signer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := signer.Verify(c.Request, time.Now())
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Set(_authPayloadKey, &token.Payload{
+			ID:          id.TokenID,
+			Username:    id.User,
+			SessionID:   id.SessionID,
+			Roles:       id.Roles,
+			Permissions: id.Permissions,
+			Scopes:      id.Scopes,
+		})
+		c.Next()
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"api--sigacore-gateway/internal/shared/identity"
+)
+
+func TestGatewayIdentity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	signer, err := identity.NewSigner("0123456789abcdefghijklmnopqrstuv", time.Minute)
+	require.NoError(t, err)
+
+	// Backend que confia no gateway e aplica as regras da rota
+	engine := gin.New()
+	engine.Use(GatewayIdentity(signer))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	engine.GET("/admin", RequireRole("admin"), ok)
+	engine.GET("/docs", RequirePermission("docs:read"), RequireScope("docs.read"), ok)
+
+	id := identity.Identity{
+		User:        "alice",
+		SessionID:   uuid.New(),
+		TokenID:     uuid.New(),
+		Roles:       []string{"editor"},
+		Permissions: []string{"docs:read"},
+		Scopes:      []string{"docs.read"},
+	}
+	send := func(target string, sign func(*http.Request)) int {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		sign(r)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, r)
+		return w.Code
+	}
+	signed := func(r *http.Request) { signer.Inject(r, id, time.Now()) }
+
+	require.Equal(t, http.StatusOK, send("/docs", signed))
+	require.Equal(t, http.StatusForbidden, send("/admin", signed))
+	require.Equal(t, http.StatusUnauthorized, send("/docs", func(*http.Request) {}))
+
+	// Assinado para uma rota e reenviado para outra
+	require.Equal(t, http.StatusUnauthorized, send("/admin", func(r *http.Request) {
+		other := httptest.NewRequest(http.MethodGet, "/docs", nil)
+		signed(other)
+		r.Header = other.Header
+	}))
+
+	// Papel acrescentado depois da assinatura
+	require.Equal(t, http.StatusUnauthorized, send("/admin", func(r *http.Request) {
+		signed(r)
+		r.Header.Set(identity.HeaderRoles, "editor,admin")
+	}))
+
+	id.Roles = append(id.Roles, "admin")
+	require.Equal(t, http.StatusOK, send("/admin", signed))
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	_errForbidden         = errors.New("insufficient permissions")
+	_errInsufficientScope = errors.New("insufficient scope")
+)
+
+// RequireRole deixa passar usuários com ao menos um dos papéis. Deve vir
+// depois do middleware de autenticação.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payload, ok := AuthPayload(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": _errMissingAuthHeader.Error()})
+			return
+		}
+
+		if !slices.ContainsFunc(roles, payload.HasRole) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": _errForbidden.Error()})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission deixa passar usuários com todas as permissões. Deve vir
+// depois do middleware de autenticação.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payload, ok := AuthPayload(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": _errMissingAuthHeader.Error()})
+			return
+		}
+
+		for _, permission := range permissions {
+			if !payload.HasPermission(permission) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": _errForbidden.Error()})
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// RequireScope deixa passar tokens com todos os escopos. Deve vir depois do
+// middleware de autenticação.
+func RequireScope(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payload, ok := AuthPayload(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": _errMissingAuthHeader.Error()})
+			return
+		}
+
+		for _, scope := range scopes {
+			if !payload.HasScope(scope) {
+				// RFC 6750: o cliente precisa de um token com mais escopos
+				c.Header("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+strings.Join(scopes, " ")+`"`)
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": _errInsufficientScope.Error()})
+				return
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"api--sigacore-gateway/internal/token"
+)
+
+// serveWithPayload executa handler como se o AuthMiddleware tivesse
+// autenticado payload (nil: requisição sem autenticação).
+func serveWithPayload(payload *token.Payload, handler gin.HandlerFunc) int {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/", func(c *gin.Context) {
+		if payload != nil {
+			c.Set(_authPayloadKey, payload)
+		}
+	}, handler, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	return w.Code
+}
+
+func TestRequireRole(t *testing.T) {
+	editor := &token.Payload{Username: "alice", Roles: []string{"editor"}}
+
+	require.Equal(t, http.StatusOK, serveWithPayload(editor, RequireRole("admin", "editor")))
+	require.Equal(t, http.StatusForbidden, serveWithPayload(editor, RequireRole("admin")))
+	require.Equal(t, http.StatusUnauthorized, serveWithPayload(nil, RequireRole("admin")))
+}
+
+func TestRequirePermission(t *testing.T) {
+	editor := &token.Payload{Username: "alice", Permissions: []string{"docs:read", "docs:write"}}
+	admin := &token.Payload{Username: "root", Permissions: []string{token.PermissionAll}}
+
+	require.Equal(t, http.StatusOK, serveWithPayload(editor, RequirePermission("docs:read", "docs:write")))
+	require.Equal(t, http.StatusForbidden, serveWithPayload(editor, RequirePermission("docs:read", "roles:manage")))
+	require.Equal(t, http.StatusOK, serveWithPayload(admin, RequirePermission("roles:manage")))
+	require.Equal(t, http.StatusUnauthorized, serveWithPayload(nil, RequirePermission("docs:read")))
+}
+
+func TestRequireScope(t *testing.T) {
+	reader := &token.Payload{Username: "alice", Scopes: []string{"docs.read"}}
+
+	require.Equal(t, http.StatusOK, serveWithPayload(reader, RequireScope("docs.read")))
+	require.Equal(t, http.StatusForbidden, serveWithPayload(reader, RequireScope("docs.read", "docs.write")))
+	require.Equal(t, http.StatusUnauthorized, serveWithPayload(nil, RequireScope("docs.read")))
+}
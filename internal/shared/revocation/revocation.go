@@ -0,0 +1,173 @@
+// Package revocation mantém a lista de tokens revogados antes de expirar.
+// A lista fica no Postgres e em memória em cada réplica: a cópia local é
+// recarregada periodicamente e atualizada na hora pelas notificações
+// (LISTEN/NOTIFY) que o banco envia a cada revogação.
+package revocation
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	db "api--sigacore-gateway/internal/db/sqlc"
+)
+
+// Channel é o canal do NOTIFY disparado pelo trigger de revoked_tokens.
+const Channel = "revoked_tokens"
+
+// List implementa token.RevocationList. Os IDs podem ser de tokens ou de
+// sessões; cada um fica na lista só até expirar.
+type List struct {
+	store           db.Store
+	refreshInterval time.Duration
+
+	mutex   sync.RWMutex
+	revoked map[uuid.UUID]time.Time
+	now     func() time.Time
+}
+
+func NewList(store db.Store, refreshInterval time.Duration) *List {
+	return &List{
+		store:           store,
+		refreshInterval: refreshInterval,
+		revoked:         make(map[uuid.UUID]time.Time),
+		now:             time.Now,
+	}
+}
+
+func (l *List) IsRevoked(id uuid.UUID) bool {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	expiresAt, ok := l.revoked[id]
+	return ok && l.now().Before(expiresAt)
+}
+
+// Revoke grava a revogação no banco e já a aplica nesta réplica; as demais
+// recebem a notificação do banco. expiresAt é quando o token (ou a sessão)
+// expiraria. Revogar um ID já revogado não é erro.
+func (l *List) Revoke(ctx context.Context, id uuid.UUID, expiresAt time.Time, reason string) error {
+	err := l.store.RevokeToken(ctx, db.RevokeTokenParams{
+		TokenID:   id,
+		ExpiresAt: expiresAt,
+		Reason:    reason,
+	})
+	if err != nil {
+		return fmt.Errorf("Revoke: %w", err)
+	}
+	l.add(id, expiresAt)
+	return nil
+}
+
+// Start carrega a lista e mantém a cópia local atualizada até ctx ser
+// cancelado. Se o banco estiver fora, a réplica sobe com a lista vazia e
+// tenta de novo no próximo ciclo.
+func (l *List) Start(ctx context.Context) {
+	if err := l.refresh(ctx); err != nil {
+		log.Printf("revocation list: initial load failed: %v", err)
+	}
+
+	go l.listen(ctx)
+	go func() {
+		ticker := time.NewTicker(l.refreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := l.refresh(ctx); err != nil && ctx.Err() == nil {
+					log.Printf("revocation list: refresh failed: %v", err)
+				}
+				if _, err := l.store.DeleteExpiredRevokedTokens(ctx); err != nil && ctx.Err() == nil {
+					log.Printf("revocation list: cannot delete expired entries: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// refresh junta as revogações do banco às locais e descarta as expiradas.
+// As entradas locais não são substituídas: uma revogação feita durante a
+// consulta não pode se perder.
+func (l *List) refresh(ctx context.Context) error {
+	rows, err := l.store.ListRevokedTokens(ctx)
+	if err != nil {
+		return err
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, row := range rows {
+		l.revoked[row.TokenID] = row.ExpiresAt
+	}
+	now := l.now()
+	for id, expiresAt := range l.revoked {
+		if !now.Before(expiresAt) {
+			delete(l.revoked, id)
+		}
+	}
+	return nil
+}
+
+// listen recebe as revogações feitas em outras réplicas. Se a conexão cair,
+// assina de novo após refreshInterval; nesse meio tempo o refresh periódico
+// cobre o que se perder.
+func (l *List) listen(ctx context.Context) {
+	for {
+		err := l.store.Listen(ctx, Channel, l.handleNotification)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("revocation list: listen failed: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(l.refreshInterval):
+		}
+	}
+}
+
+// handleNotification trata o payload "<id> <expires_at em segundos unix>".
+func (l *List) handleNotification(payload string) {
+	id, expiresAt, err := parseNotification(payload)
+	if err != nil {
+		log.Printf("revocation list: %v", err)
+		return
+	}
+	l.add(id, expiresAt)
+}
+
+func parseNotification(payload string) (uuid.UUID, time.Time, error) {
+	rawID, rawExpiresAt, ok := strings.Cut(payload, " ")
+	if !ok {
+		return uuid.Nil, time.Time{}, fmt.Errorf("malformed notification %q", payload)
+	}
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return uuid.Nil, time.Time{}, fmt.Errorf("malformed notification %q: %w", payload, err)
+	}
+	seconds, err := strconv.ParseInt(rawExpiresAt, 10, 64)
+	if err != nil {
+		return uuid.Nil, time.Time{}, fmt.Errorf("malformed notification %q: %w", payload, err)
+	}
+	return id, time.Unix(seconds, 0), nil
+}
+
+func (l *List) add(id uuid.UUID, expiresAt time.Time) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if current, ok := l.revoked[id]; !ok || expiresAt.After(current) {
+		l.revoked[id] = expiresAt
+	}
+}
@@ -0,0 +1,40 @@
+package revocation
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListNotification(t *testing.T) {
+	list := NewList(nil, time.Minute)
+	now := time.Now()
+	list.now = func() time.Time { return now }
+
+	id := uuid.New()
+	require.False(t, list.IsRevoked(id))
+
+	list.handleNotification(fmt.Sprintf("%s %d", id, now.Add(time.Hour).Unix()))
+	require.True(t, list.IsRevoked(id))
+	require.False(t, list.IsRevoked(uuid.New()))
+
+	// Depois de expirar o token não vale mais: a entrada é ignorada
+	now = now.Add(2 * time.Hour)
+	require.False(t, list.IsRevoked(id))
+}
+
+func TestParseNotification(t *testing.T) {
+	id := uuid.New()
+	gotID, expiresAt, err := parseNotification(fmt.Sprintf("%s 1700000000", id))
+	require.NoError(t, err)
+	require.Equal(t, id, gotID)
+	require.Equal(t, time.Unix(1700000000, 0), expiresAt)
+
+	for _, payload := range []string{"", id.String(), "not-a-uuid 1700000000", id.String() + " soon"} {
+		_, _, err := parseNotification(payload)
+		require.Error(t, err, payload)
+	}
+}
@@ -0,0 +1,55 @@
+package token
+
+import (
+	"slices"
+	"time"
+)
+
+// ClaimsMaker grava issuer e audience em todo token que emite e recusa, na
+// verificação, tokens de outro issuer ou audience. Assim um token emitido em
+// um ambiente (ou para outro conjunto de serviços) não é aceito aqui.
+type ClaimsMaker struct {
+	maker    Maker
+	issuer   string
+	audience string
+}
+
+// NewClaimsMaker envolve maker. issuer ou audience vazios não são verificados.
+func NewClaimsMaker(maker Maker, issuer, audience string) *ClaimsMaker {
+	return &ClaimsMaker{maker: maker, issuer: issuer, audience: audience}
+}
+
+func (cm *ClaimsMaker) CreateToken(username string, duration time.Duration, opts ...PayloadOption) (string, *Payload, error) {
+	// Cópia: append direto em opts poderia escrever no array de quem chamou
+	opts = slices.Clone(opts)
+	if cm.issuer != "" {
+		opts = append(opts, WithIssuer(cm.issuer))
+	}
+	if cm.audience != "" {
+		opts = append(opts, WithAudience(cm.audience))
+	}
+	return cm.maker.CreateToken(username, duration, opts...)
+}
+
+func (cm *ClaimsMaker) VerifyToken(token string) (*Payload, error) {
+	payload, err := cm.maker.VerifyToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if cm.issuer != "" && payload.Issuer != cm.issuer {
+		return nil, &TokenError{Op: "issuer", Err: ErrTokenIssuer}
+	}
+	if cm.audience != "" && payload.Audience != cm.audience {
+		return nil, &TokenError{Op: "audience", Err: ErrTokenAudience}
+	}
+	return payload, nil
+}
+
+// JWKS repassa as chaves públicas do maker envolvido, se houver.
+func (cm *ClaimsMaker) JWKS() JWKS {
+	if provider, ok := cm.maker.(JWKSProvider); ok {
+		return provider.JWKS()
+	}
+	return JWKS{Keys: []JWK{}}
+}
@@ -0,0 +1,84 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"api--sigacore-gateway/internal/util"
+)
+
+func TestClaimsMaker(t *testing.T) {
+	production := NewClaimsMaker(newTestPasetoMaker(t, _testInternalKey), "sigacore-auth-production", "sigacore")
+
+	signed, payload, err := production.CreateToken("alice", time.Minute, WithScopes("docs.read", "docs.write"))
+	require.NoError(t, err)
+	require.Equal(t, "sigacore-auth-production", payload.Issuer)
+	require.Equal(t, "sigacore", payload.Audience)
+
+	verified, err := production.VerifyToken(signed)
+	require.NoError(t, err)
+	require.True(t, verified.HasScope("docs.write"))
+	require.False(t, verified.HasScope("admin"))
+
+	// Mesma chave, outro ambiente: o issuer não bate
+	staging := NewClaimsMaker(newTestPasetoMaker(t, _testInternalKey), "sigacore-auth-staging", "sigacore")
+	_, err = staging.VerifyToken(signed)
+	require.ErrorIs(t, err, ErrTokenIssuer)
+
+	// Mesmo issuer, outro conjunto de serviços
+	other := NewClaimsMaker(newTestPasetoMaker(t, _testInternalKey), "sigacore-auth-production", "billing")
+	_, err = other.VerifyToken(signed)
+	require.ErrorIs(t, err, ErrTokenAudience)
+
+	// Tokens sem os claims são recusados
+	unbound, _, err := newTestPasetoMaker(t, _testInternalKey).CreateToken("alice", time.Minute)
+	require.NoError(t, err)
+	_, err = production.VerifyToken(unbound)
+	require.ErrorIs(t, err, ErrTokenIssuer)
+}
+
+func TestMFAMakerFromConfig(t *testing.T) {
+	cfg := util.Config{
+		TokenSymmetricKey: _testInternalKey,
+		TokenIssuer:       "sigacore-auth-production",
+		TokenAudience:     "sigacore",
+	}
+	maker, err := NewMakerFromConfig(cfg)
+	require.NoError(t, err)
+	mfaMaker, err := NewMFAMakerFromConfig(cfg)
+	require.NoError(t, err)
+
+	challenge, payload, err := mfaMaker.CreateToken("alice", time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, MFAAudience("sigacore"), payload.Audience)
+
+	_, err = mfaMaker.VerifyToken(challenge)
+	require.NoError(t, err)
+
+	// Um desafio não serve de access token, nem o contrário
+	_, err = maker.VerifyToken(challenge)
+	require.ErrorIs(t, err, ErrTokenAudience)
+
+	access, _, err := maker.CreateToken("alice", time.Minute)
+	require.NoError(t, err)
+	_, err = mfaMaker.VerifyToken(access)
+	require.ErrorIs(t, err, ErrTokenAudience)
+}
+
+func TestClaimsMakerDoesNotModifyOptions(t *testing.T) {
+	maker := NewClaimsMaker(newTestPasetoMaker(t, _testInternalKey), "sigacore-auth-test", "sigacore")
+
+	// Com capacidade sobrando, um append em opts escreveria em opts[1]
+	opts := make([]PayloadOption, 2)
+	opts[0], opts[1] = WithRoles([]string{"editor"}, nil), WithScopes("docs.read")
+	opts = opts[:1]
+
+	_, _, err := maker.CreateToken("alice", time.Minute, opts...)
+	require.NoError(t, err)
+
+	_, payload, err := maker.CreateToken("alice", time.Minute, opts[:2]...)
+	require.NoError(t, err)
+	require.Equal(t, []string{"docs.read"}, payload.Scopes)
+}
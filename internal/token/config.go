@@ -0,0 +1,178 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"api--sigacore-gateway/internal/util"
+)
+
+// NewMakerFromConfig cria o Maker escolhido em TOKEN_TYPE. Com
+// TOKEN_KEYRING_FILE, o Maker é um Keyring com as chaves do arquivo. Os tokens
+// levam e exigem TOKEN_ISSUER e TOKEN_AUDIENCE.
+func NewMakerFromConfig(cfg util.Config) (Maker, error) {
+	maker, err := newConfigMaker(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("NewMakerFromConfig: %w", err)
+	}
+	return NewClaimsMaker(maker, cfg.TokenIssuer, cfg.TokenAudience), nil
+}
+
+// MFAAudience é o audience dos tokens de desafio MFA emitidos para audience.
+func MFAAudience(audience string) string {
+	return audience + ":mfa"
+}
+
+// NewMFAMakerFromConfig cria o Maker dos tokens de desafio MFA, emitidos
+// entre a senha e o segundo fator. Usa as mesmas chaves de
+// NewMakerFromConfig com outro audience, então um desafio é recusado como
+// access token em todo serviço que verifica TOKEN_AUDIENCE.
+func NewMFAMakerFromConfig(cfg util.Config) (Maker, error) {
+	maker, err := newConfigMaker(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("NewMFAMakerFromConfig: %w", err)
+	}
+	return NewClaimsMaker(maker, cfg.TokenIssuer, MFAAudience(cfg.TokenAudience)), nil
+}
+
+func newConfigMaker(cfg util.Config) (Maker, error) {
+	if cfg.TokenKeyringFile != "" {
+		return LoadKeyring(cfg.TokenKeyringFile, cfg)
+	}
+	return newMaker(cfg, keyMaterial{
+		SymmetricKey:   cfg.TokenSymmetricKey,
+		PrivateKeyFile: cfg.TokenPrivateKeyFile,
+		PublicKeyFile:  cfg.TokenPublicKeyFile,
+	})
+}
+
+// keyringFile é o formato de TOKEN_KEYRING_FILE. O tipo de token e o algoritmo
+// continuam vindo de TOKEN_TYPE e TOKEN_JWT_ALGORITHM.
+type keyringFile struct {
+	Keys []keyringFileEntry `yaml:"keys"`
+}
+
+type keyringFileEntry struct {
+	ID          string `yaml:"id"`
+	keyMaterial `yaml:",inline"`
+	ActivateAt  time.Time `yaml:"activate_at"`
+	RetireAt    time.Time `yaml:"retire_at"`
+}
+
+// keyMaterial são as chaves de um maker: a simétrica para paseto e jwt HS256,
+// os arquivos para os tipos assimétricos.
+type keyMaterial struct {
+	// SymmetricKey aceita referências como ${TOKEN_KEY_2026_10}, resolvidas do
+	// ambiente, para que o arquivo não precise guardar o segredo
+	SymmetricKey   string `yaml:"symmetric_key"`
+	PrivateKeyFile string `yaml:"private_key_file"`
+	PublicKeyFile  string `yaml:"public_key_file"`
+}
+
+// LoadKeyring lê o keyring de um arquivo YAML e cria um maker do TOKEN_TYPE
+// configurado para cada chave.
+func LoadKeyring(path string, cfg util.Config) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("LoadKeyring: %w", err)
+	}
+
+	var file keyringFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("LoadKeyring: %s: %w", path, err)
+	}
+
+	entries := make([]KeyringEntry, 0, len(file.Keys))
+	for _, key := range file.Keys {
+		key.SymmetricKey = os.ExpandEnv(key.SymmetricKey)
+		maker, err := newMaker(cfg, key.keyMaterial)
+		if err != nil {
+			return nil, fmt.Errorf("LoadKeyring: %s: key %q: %w", path, key.ID, err)
+		}
+		entries = append(entries, KeyringEntry{
+			ID:         key.ID,
+			Maker:      maker,
+			ActivateAt: key.ActivateAt,
+			RetireAt:   key.RetireAt,
+		})
+	}
+
+	keyring, err := NewKeyring(entries...)
+	if err != nil {
+		return nil, fmt.Errorf("LoadKeyring: %s: %w", path, err)
+	}
+	return keyring, nil
+}
+
+func newMaker(cfg util.Config, keys keyMaterial) (Maker, error) {
+	switch cfg.TokenType {
+	case util.TokenTypePaseto, "":
+		return NewPasetoMaker(keys.SymmetricKey)
+	case util.TokenTypePasetoPublic:
+		privateKey, publicKey, err := loadEd25519Keys(keys)
+		if err != nil {
+			return nil, err
+		}
+		return NewPublicPasetoMaker(privateKey, publicKey)
+	case util.TokenTypeJWT:
+		return newJWTMaker(cfg.TokenJWTAlgorithm, keys)
+	default:
+		return nil, fmt.Errorf("unsupported token type %q", cfg.TokenType)
+	}
+}
+
+func newJWTMaker(algorithm string, keys keyMaterial) (Maker, error) {
+	switch algorithm {
+	case util.JWTAlgorithmHS256, "":
+		return NewHS256JWTMaker(keys.SymmetricKey)
+	case util.JWTAlgorithmRS256:
+		privateKey, publicKey, err := loadRSAKeys(keys)
+		if err != nil {
+			return nil, err
+		}
+		return NewRS256JWTMaker(privateKey, publicKey)
+	case util.JWTAlgorithmEdDSA:
+		privateKey, publicKey, err := loadEd25519Keys(keys)
+		if err != nil {
+			return nil, err
+		}
+		return NewEdDSAJWTMaker(privateKey, publicKey)
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", algorithm)
+	}
+}
+
+// loadEd25519Keys lê as chaves configuradas; as ausentes ficam nil.
+func loadEd25519Keys(keys keyMaterial) (privateKey ed25519.PrivateKey, publicKey ed25519.PublicKey, err error) {
+	if keys.PrivateKeyFile != "" {
+		if privateKey, err = LoadEd25519PrivateKey(keys.PrivateKeyFile); err != nil {
+			return nil, nil, err
+		}
+	}
+	if keys.PublicKeyFile != "" {
+		if publicKey, err = LoadEd25519PublicKey(keys.PublicKeyFile); err != nil {
+			return nil, nil, err
+		}
+	}
+	return privateKey, publicKey, nil
+}
+
+// loadRSAKeys lê as chaves configuradas; as ausentes ficam nil.
+func loadRSAKeys(keys keyMaterial) (privateKey *rsa.PrivateKey, publicKey *rsa.PublicKey, err error) {
+	if keys.PrivateKeyFile != "" {
+		if privateKey, err = LoadRSAPrivateKey(keys.PrivateKeyFile); err != nil {
+			return nil, nil, err
+		}
+	}
+	if keys.PublicKeyFile != "" {
+		if publicKey, err = LoadRSAPublicKey(keys.PublicKeyFile); err != nil {
+			return nil, nil, err
+		}
+	}
+	return privateKey, publicKey, nil
+}
@@ -0,0 +1,16 @@
+package token
+
+import "context"
+
+type payloadContextKey struct{}
+
+// NewContext retorna uma cópia de ctx carregando o payload de um token verificado.
+func NewContext(ctx context.Context, payload *Payload) context.Context {
+	return context.WithValue(ctx, payloadContextKey{}, payload)
+}
+
+// FromContext retorna o payload guardado por NewContext, se houver.
+func FromContext(ctx context.Context) (*Payload, bool) {
+	payload, ok := ctx.Value(payloadContextKey{}).(*Payload)
+	return payload, ok
+}
@@ -9,6 +9,9 @@ var (
 	ErrTokenInvalid   = errors.New("token is invalid")
 	ErrTokenExpired   = errors.New("token has expired")
 	ErrTokenMalformed = errors.New("token is malformed")
+	ErrTokenAudience  = errors.New("token audience is invalid")
+	ErrTokenIssuer    = errors.New("token issuer is invalid")
+	ErrTokenRevoked   = errors.New("token has been revoked")
 )
 
 type TokenError struct {
@@ -0,0 +1,50 @@
+package token
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// InternalMaker emite e verifica os tokens internos que o gateway troca pelo
+// token do usuário antes de chamar um backend. Usa uma chave própria e fica
+// preso a um audience: um token emitido para um serviço é recusado pelos
+// outros, o que limita o estrago se um backend vazar tokens.
+type InternalMaker struct {
+	maker    *PasetoMaker
+	audience string
+}
+
+// NewInternalMaker cria o maker do serviço audience. O gateway cria um por
+// backend; cada backend cria o seu com o próprio nome para verificar.
+func NewInternalMaker(symmetricKey, audience string) (Maker, error) {
+	if audience == "" {
+		return nil, fmt.Errorf("NewInternalMaker: audience is required")
+	}
+
+	maker, err := NewPasetoMaker(symmetricKey)
+	if err != nil {
+		return nil, fmt.Errorf("NewInternalMaker: %w", err)
+	}
+	return &InternalMaker{
+		maker:    maker.(*PasetoMaker),
+		audience: audience,
+	}, nil
+}
+
+func (im *InternalMaker) CreateToken(username string, duration time.Duration, opts ...PayloadOption) (string, *Payload, error) {
+	// Cópia: append direto em opts poderia escrever no array de quem chamou
+	return im.maker.CreateToken(username, duration, append(slices.Clone(opts), WithAudience(im.audience))...)
+}
+
+func (im *InternalMaker) VerifyToken(token string) (*Payload, error) {
+	payload, err := im.maker.VerifyToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if payload.Audience != im.audience {
+		return nil, &TokenError{Op: "audience", Err: ErrTokenAudience}
+	}
+	return payload, nil
+}
@@ -0,0 +1,71 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+const _testInternalKey = "0123456789abcdefghijklmnopqrstuv"
+
+func TestInternalMaker(t *testing.T) {
+	docs, err := NewInternalMaker(_testInternalKey, "docs")
+	require.NoError(t, err)
+
+	sessionID := uuid.New()
+	internalToken, payload, err := docs.CreateToken("alice", time.Minute, WithSessionID(sessionID))
+	require.NoError(t, err)
+	require.Equal(t, "docs", payload.Audience)
+
+	verified, err := docs.VerifyToken(internalToken)
+	require.NoError(t, err)
+	require.Equal(t, "alice", verified.Username)
+	require.Equal(t, sessionID, verified.SessionID)
+	require.Equal(t, "docs", verified.Audience)
+
+	// Um token emitido para outro serviço é recusado
+	users, err := NewInternalMaker(_testInternalKey, "users")
+	require.NoError(t, err)
+	_, err = users.VerifyToken(internalToken)
+	require.ErrorIs(t, err, ErrTokenAudience)
+
+	// Tokens de usuário (outra chave, sem audience) também
+	userMaker, err := NewPasetoMaker("vutsrqponmlkjihgfedcba9876543210")
+	require.NoError(t, err)
+	userToken, _, err := userMaker.CreateToken("alice", time.Minute)
+	require.NoError(t, err)
+	_, err = docs.VerifyToken(userToken)
+	require.ErrorIs(t, err, ErrTokenInvalid)
+}
+
+func TestInternalMakerExpired(t *testing.T) {
+	docs, err := NewInternalMaker(_testInternalKey, "docs")
+	require.NoError(t, err)
+
+	internalToken, _, err := docs.CreateToken("alice", -time.Second)
+	require.NoError(t, err)
+
+	_, err = docs.VerifyToken(internalToken)
+	require.ErrorIs(t, err, ErrTokenExpired)
+}
+
+func TestInternalMakerDoesNotModifyOptions(t *testing.T) {
+	docs, err := NewInternalMaker(_testInternalKey, "docs")
+	require.NoError(t, err)
+
+	// Com capacidade sobrando, um append em opts escreveria em opts[1]
+	sessionID := uuid.New()
+	opts := make([]PayloadOption, 2)
+	opts[0], opts[1] = WithSessionID(sessionID), WithScopes("docs.read")
+	opts = opts[:1]
+
+	_, payload, err := docs.CreateToken("alice", time.Minute, opts...)
+	require.NoError(t, err)
+	require.Equal(t, "docs", payload.Audience)
+
+	_, payload, err = docs.CreateToken("alice", time.Minute, opts[:2]...)
+	require.NoError(t, err)
+	require.Equal(t, []string{"docs.read"}, payload.Scopes)
+}
@@ -0,0 +1,60 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+)
+
+// JWK é a chave pública de verificação no formato JSON Web Key (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	// Curve e X descrevem chaves Ed25519 (kty OKP, RFC 8037)
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	// N e E descrevem chaves RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+}
+
+// JWKS é o documento servido em /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKSProvider é implementado pelos makers que verificam com chave pública.
+type JWKSProvider interface {
+	JWKS() JWKS
+}
+
+// publicKeyMaker é implementado pelos makers que sabem exportar a própria
+// chave pública; makers simétricos retornam false.
+type publicKeyMaker interface {
+	publicJWK() (JWK, bool)
+}
+
+func newEd25519JWK(keyID, alg string, key ed25519.PublicKey) JWK {
+	return JWK{
+		KeyType:   "OKP",
+		KeyID:     keyID,
+		Use:       "sig",
+		Algorithm: alg,
+		Curve:     "Ed25519",
+		X:         base64.RawURLEncoding.EncodeToString(key),
+	}
+}
+
+func newRSAJWK(keyID, alg string, key *rsa.PublicKey) JWK {
+	return JWK{
+		KeyType:   "RSA",
+		KeyID:     keyID,
+		Use:       "sig",
+		Algorithm: alg,
+		N:         base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
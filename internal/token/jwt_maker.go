@@ -0,0 +1,211 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// jwtClaims é a representação do Payload em JWT, usando os registered claims
+// (jti, sub, aud, iat, nbf, exp) para que consumidores de JWT entendam o token.
+type jwtClaims struct {
+	jwt.RegisteredClaims
+	Username    string   `json:"username"`
+	SessionID   string   `json:"sid,omitempty"`
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	// Scope segue a RFC 9068: escopos separados por espaço
+	Scope string `json:"scope,omitempty"`
+}
+
+func newJWTClaims(payload *Payload) jwtClaims {
+	claims := jwtClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        payload.ID.String(),
+			Issuer:    payload.Issuer,
+			Subject:   payload.Username,
+			IssuedAt:  jwt.NewNumericDate(payload.IssuedAt),
+			NotBefore: jwt.NewNumericDate(payload.IssuedAt),
+			ExpiresAt: jwt.NewNumericDate(payload.ExpiredAt),
+		},
+		Username:    payload.Username,
+		Roles:       payload.Roles,
+		Permissions: payload.Permissions,
+		Scope:       strings.Join(payload.Scopes, " "),
+	}
+	if payload.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{payload.Audience}
+	}
+	if payload.SessionID != uuid.Nil {
+		claims.SessionID = payload.SessionID.String()
+	}
+	return claims
+}
+
+func (c *jwtClaims) payload() (*Payload, error) {
+	id, err := uuid.Parse(c.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := &Payload{
+		ID:          id,
+		Username:    c.Username,
+		Issuer:      c.Issuer,
+		Roles:       c.Roles,
+		Permissions: c.Permissions,
+		Scopes:      strings.Fields(c.Scope),
+	}
+	if payload.Username == "" {
+		payload.Username = c.Subject
+	}
+	if c.SessionID != "" {
+		if payload.SessionID, err = uuid.Parse(c.SessionID); err != nil {
+			return nil, err
+		}
+	}
+	if len(c.Audience) > 0 {
+		payload.Audience = c.Audience[0]
+	}
+	if c.IssuedAt != nil {
+		payload.IssuedAt = c.IssuedAt.Time
+	}
+	if c.ExpiresAt != nil {
+		payload.ExpiredAt = c.ExpiresAt.Time
+	}
+	return payload, nil
+}
+
+// JWTMaker emite tokens JWT com HS256, RS256 ou EdDSA, para consumidores que
+// não entendem PASETO. Com algoritmos assimétricos, um maker sem chave privada
+// só verifica tokens.
+type JWTMaker struct {
+	method     jwt.SigningMethod
+	signingKey any
+	verifyKey  any
+	keyID      string
+}
+
+// NewHS256JWTMaker cria um JWTMaker HMAC com a chave simétrica.
+func NewHS256JWTMaker(symmetricKey string) (Maker, error) {
+	if len(symmetricKey) < 32 {
+		return nil, fmt.Errorf("NewHS256JWTMaker: key must have at least 32 characters")
+	}
+	key := []byte(symmetricKey)
+	return &JWTMaker{method: jwt.SigningMethodHS256, signingKey: key, verifyKey: key}, nil
+}
+
+// NewRS256JWTMaker cria um JWTMaker RSA. privateKey pode ser nil para só verificar.
+func NewRS256JWTMaker(privateKey *rsa.PrivateKey, publicKey *rsa.PublicKey) (Maker, error) {
+	if privateKey != nil {
+		if privateKey.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("NewRS256JWTMaker: RSA key must have at least 2048 bits")
+		}
+		if publicKey != nil && !publicKey.Equal(&privateKey.PublicKey) {
+			return nil, fmt.Errorf("NewRS256JWTMaker: public key does not match private key")
+		}
+		publicKey = &privateKey.PublicKey
+	}
+	if publicKey == nil {
+		return nil, fmt.Errorf("NewRS256JWTMaker: a private or public key is required")
+	}
+
+	maker := &JWTMaker{method: jwt.SigningMethodRS256, verifyKey: publicKey}
+	if privateKey != nil {
+		maker.signingKey = privateKey
+	}
+	return maker, nil
+}
+
+// NewEdDSAJWTMaker cria um JWTMaker Ed25519. privateKey pode ser nil para só verificar.
+func NewEdDSAJWTMaker(privateKey ed25519.PrivateKey, publicKey ed25519.PublicKey) (Maker, error) {
+	if privateKey != nil {
+		derived := privateKey.Public().(ed25519.PublicKey)
+		if publicKey != nil && !publicKey.Equal(derived) {
+			return nil, fmt.Errorf("NewEdDSAJWTMaker: public key does not match private key")
+		}
+		publicKey = derived
+	}
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("NewEdDSAJWTMaker: a private or public key is required")
+	}
+
+	maker := &JWTMaker{method: jwt.SigningMethodEdDSA, verifyKey: publicKey}
+	if privateKey != nil {
+		maker.signingKey = privateKey
+	}
+	return maker, nil
+}
+
+func (jm *JWTMaker) CreateToken(username string, duration time.Duration, opts ...PayloadOption) (string, *Payload, error) {
+	if jm.signingKey == nil {
+		return "", nil, fmt.Errorf("CreateToken: %w", ErrVerifyOnly)
+	}
+
+	payload, err := NewPayload(username, duration, opts...)
+	if err != nil {
+		return "", nil, fmt.Errorf("CreateToken: %w", err)
+	}
+
+	unsigned := jwt.NewWithClaims(jm.method, newJWTClaims(payload))
+	if jm.keyID != "" {
+		unsigned.Header["kid"] = jm.keyID
+	}
+	token, err := unsigned.SignedString(jm.signingKey)
+	if err != nil {
+		return "", nil, fmt.Errorf("CreateToken: %w", err)
+	}
+	return token, payload, nil
+}
+
+func (jm *JWTMaker) VerifyToken(token string) (*Payload, error) {
+	claims := &jwtClaims{}
+	_, err := jwt.ParseWithClaims(token, claims,
+		func(*jwt.Token) (any, error) { return jm.verifyKey, nil },
+		// Fixar o algoritmo impede tokens "alg: none" ou HS256 assinados com a
+		// chave pública RSA
+		jwt.WithValidMethods([]string{jm.method.Alg()}),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, &TokenError{Op: "validate", Err: ErrTokenExpired}
+		}
+		return nil, &TokenError{Op: "verify", Err: ErrTokenInvalid}
+	}
+
+	payload, err := claims.payload()
+	if err != nil {
+		return nil, &TokenError{Op: "decode", Err: ErrTokenMalformed}
+	}
+	return payload, nil
+}
+
+// JWKS publica a chave pública de verificação; makers HS256 não publicam nada.
+func (jm *JWTMaker) JWKS() JWKS {
+	if jwk, ok := jm.publicJWK(); ok {
+		return JWKS{Keys: []JWK{jwk}}
+	}
+	return JWKS{Keys: []JWK{}}
+}
+
+func (jm *JWTMaker) publicJWK() (JWK, bool) {
+	switch key := jm.verifyKey.(type) {
+	case *rsa.PublicKey:
+		return newRSAJWK(jm.keyID, jm.method.Alg(), key), true
+	case ed25519.PublicKey:
+		return newEd25519JWK(jm.keyID, jm.method.Alg(), key), true
+	default:
+		return JWK{}, false
+	}
+}
+
+func (jm *JWTMaker) setKeyID(keyID string) {
+	jm.keyID = keyID
+}
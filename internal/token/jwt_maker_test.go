@@ -0,0 +1,144 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+const _testJWTKey = "0123456789abcdefghijklmnopqrstuv"
+
+func TestJWTMaker(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	hs256, err := NewHS256JWTMaker(_testJWTKey)
+	require.NoError(t, err)
+	rs256, err := NewRS256JWTMaker(rsaKey, nil)
+	require.NoError(t, err)
+	rs256Verifier, err := NewRS256JWTMaker(nil, &rsaKey.PublicKey)
+	require.NoError(t, err)
+	eddsa, err := NewEdDSAJWTMaker(edPrivate, nil)
+	require.NoError(t, err)
+	eddsaVerifier, err := NewEdDSAJWTMaker(nil, edPublic)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		issuer   Maker
+		verifier Maker
+	}{
+		{"HS256", hs256, hs256},
+		{"RS256", rs256, rs256Verifier},
+		{"EdDSA", eddsa, eddsaVerifier},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sessionID := uuid.New()
+			signed, payload, err := tc.issuer.CreateToken("alice", time.Minute,
+				WithSessionID(sessionID), WithAudience("docs"), WithIssuer("sigacore-auth"),
+				WithScopes("docs.read", "docs.write"))
+			require.NoError(t, err)
+
+			verified, err := tc.verifier.VerifyToken(signed)
+			require.NoError(t, err)
+			require.Equal(t, payload.ID, verified.ID)
+			require.Equal(t, "alice", verified.Username)
+			require.Equal(t, sessionID, verified.SessionID)
+			require.Equal(t, "docs", verified.Audience)
+			require.Equal(t, "sigacore-auth", verified.Issuer)
+			require.Equal(t, []string{"docs.read", "docs.write"}, verified.Scopes)
+			require.WithinDuration(t, payload.ExpiredAt, verified.ExpiredAt, time.Second)
+
+			// Os registered claims ficam legíveis para qualquer consumidor de JWT
+			claims := jwt.MapClaims{}
+			_, _, err = jwt.NewParser().ParseUnverified(signed, claims)
+			require.NoError(t, err)
+			require.Equal(t, "alice", claims["sub"])
+			require.Equal(t, "sigacore-auth", claims["iss"])
+			require.Equal(t, payload.ID.String(), claims["jti"])
+			require.Equal(t, "docs.read docs.write", claims["scope"])
+
+			expired, _, err := tc.issuer.CreateToken("alice", -time.Minute)
+			require.NoError(t, err)
+			_, err = tc.verifier.VerifyToken(expired)
+			require.ErrorIs(t, err, ErrTokenExpired)
+		})
+	}
+
+	_, _, err = rs256Verifier.CreateToken("mallory", time.Minute)
+	require.ErrorIs(t, err, ErrVerifyOnly)
+}
+
+func TestJWTMakerRejectsOtherAlgorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	verifier, err := NewRS256JWTMaker(nil, &rsaKey.PublicKey)
+	require.NoError(t, err)
+
+	payload, err := NewPayload("mallory", time.Minute)
+	require.NoError(t, err)
+	claims := newJWTClaims(payload)
+
+	// HS256 assinado com a chave pública RSA
+	publicDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(publicPEM)
+	require.NoError(t, err)
+	_, err = verifier.VerifyToken(forged)
+	require.ErrorIs(t, err, ErrTokenInvalid)
+
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	_, err = verifier.VerifyToken(unsigned)
+	require.ErrorIs(t, err, ErrTokenInvalid)
+
+	// Outra chave HMAC
+	hs256, err := NewHS256JWTMaker(_testJWTKey)
+	require.NoError(t, err)
+	other, err := NewHS256JWTMaker("vutsrqponmlkjihgfedcba9876543210")
+	require.NoError(t, err)
+	signed, _, err := other.CreateToken("mallory", time.Minute)
+	require.NoError(t, err)
+	_, err = hs256.VerifyToken(signed)
+	require.ErrorIs(t, err, ErrTokenInvalid)
+}
+
+func TestLoadRSAKeys(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	dir := t.TempDir()
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+
+	privatePath := filepath.Join(dir, "private.pem")
+	publicPath := filepath.Join(dir, "public.pem")
+	require.NoError(t, os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0o600))
+	require.NoError(t, os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0o600))
+
+	privateKey, err := LoadRSAPrivateKey(privatePath)
+	require.NoError(t, err)
+	require.True(t, rsaKey.Equal(privateKey))
+	publicKey, err := LoadRSAPublicKey(publicPath)
+	require.NoError(t, err)
+	require.True(t, rsaKey.PublicKey.Equal(publicKey))
+
+	_, err = LoadRSAPublicKey(privatePath)
+	require.ErrorIs(t, err, ErrInvalidKey)
+}
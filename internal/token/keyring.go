@@ -0,0 +1,190 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/o1egl/paseto"
+)
+
+var ErrNoActiveKey = errors.New("keyring has no active signing key")
+
+// footer é o rodapé (não cifrado, mas autenticado) dos tokens PASETO.
+type footer struct {
+	KeyID string `json:"kid"`
+}
+
+// newFooter retorna nil sem kid, para que tokens de makers fora de um keyring
+// continuem sem rodapé.
+func newFooter(keyID string) any {
+	if keyID == "" {
+		return nil
+	}
+	return footer{KeyID: keyID}
+}
+
+// keyedMaker é implementado pelos makers que carregam o kid no token: no
+// rodapé em PASETO e no cabeçalho em JWT.
+type keyedMaker interface {
+	Maker
+	setKeyID(keyID string)
+}
+
+// KeyringEntry é uma chave do keyring e a janela em que ela vale.
+type KeyringEntry struct {
+	ID    string
+	Maker Maker
+	// ActivateAt é quando a chave passa a assinar tokens. Antes disso ela já
+	// é aceita na verificação e publicada no JWKS, para que os verificadores
+	// a conheçam antes do primeiro token
+	ActivateAt time.Time
+	// RetireAt é quando a chave deixa de ser aceita; zero significa sem prazo.
+	// Deve ficar depois da ativação da próxima chave somada à duração do
+	// refresh token, ou sessões ainda válidas serão derrubadas
+	RetireAt time.Time
+}
+
+func (e *KeyringEntry) retired(now time.Time) bool {
+	return !e.RetireAt.IsZero() && !now.Before(e.RetireAt)
+}
+
+// Keyring é um Maker com várias chaves: assina com a chave ativa mais recente
+// e verifica com qualquer chave não aposentada, escolhida pelo kid do token.
+// Assim a troca de chave não invalida os tokens já emitidos.
+type Keyring struct {
+	entries []KeyringEntry
+	byID    map[string]*KeyringEntry
+	now     func() time.Time
+}
+
+// NewKeyring cria o keyring. Todos os makers precisam ser do mesmo tipo de
+// token; o kid de cada entrada é gravado nos tokens que ela assina.
+func NewKeyring(entries ...KeyringEntry) (*Keyring, error) {
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("NewKeyring: at least one key is required")
+	}
+
+	kr := &Keyring{
+		entries: append([]KeyringEntry(nil), entries...),
+		byID:    make(map[string]*KeyringEntry, len(entries)),
+		now:     time.Now,
+	}
+	sort.SliceStable(kr.entries, func(i, j int) bool {
+		return kr.entries[i].ActivateAt.Before(kr.entries[j].ActivateAt)
+	})
+
+	for i := range kr.entries {
+		entry := &kr.entries[i]
+		if entry.ID == "" {
+			return nil, fmt.Errorf("NewKeyring: key %d has no id", i)
+		}
+		if _, ok := kr.byID[entry.ID]; ok {
+			return nil, fmt.Errorf("NewKeyring: duplicate key id %q", entry.ID)
+		}
+		if !entry.RetireAt.IsZero() && !entry.RetireAt.After(entry.ActivateAt) {
+			return nil, fmt.Errorf("NewKeyring: key %q retires before it activates", entry.ID)
+		}
+		maker, ok := entry.Maker.(keyedMaker)
+		if !ok {
+			return nil, fmt.Errorf("NewKeyring: key %q: maker %T does not support key ids", entry.ID, entry.Maker)
+		}
+		maker.setKeyID(entry.ID)
+		kr.byID[entry.ID] = entry
+	}
+	return kr, nil
+}
+
+// Active retorna a chave que assina tokens agora: a ativada mais recentemente
+// entre as que não foram aposentadas.
+func (kr *Keyring) Active() (*KeyringEntry, error) {
+	now := kr.now()
+	for i := len(kr.entries) - 1; i >= 0; i-- {
+		entry := &kr.entries[i]
+		if !entry.ActivateAt.After(now) && !entry.retired(now) {
+			return entry, nil
+		}
+	}
+	return nil, ErrNoActiveKey
+}
+
+func (kr *Keyring) CreateToken(username string, duration time.Duration, opts ...PayloadOption) (string, *Payload, error) {
+	entry, err := kr.Active()
+	if err != nil {
+		return "", nil, fmt.Errorf("CreateToken: %w", err)
+	}
+	return entry.Maker.CreateToken(username, duration, opts...)
+}
+
+func (kr *Keyring) VerifyToken(token string) (*Payload, error) {
+	keyID, err := tokenKeyID(token)
+	if err != nil {
+		return nil, &TokenError{Op: "decode", Err: ErrTokenMalformed}
+	}
+
+	now := kr.now()
+	if keyID != "" {
+		entry, ok := kr.byID[keyID]
+		if !ok || entry.retired(now) {
+			return nil, &TokenError{Op: "verify", Err: ErrTokenInvalid}
+		}
+		return entry.Maker.VerifyToken(token)
+	}
+
+	// Tokens sem kid foram emitidos antes do keyring: tenta cada chave válida
+	err = &TokenError{Op: "verify", Err: ErrTokenInvalid}
+	for i := len(kr.entries) - 1; i >= 0; i-- {
+		entry := &kr.entries[i]
+		if entry.retired(now) {
+			continue
+		}
+		payload, verifyErr := entry.Maker.VerifyToken(token)
+		if verifyErr == nil {
+			return payload, nil
+		}
+		if errors.Is(verifyErr, ErrTokenExpired) {
+			err = verifyErr
+		}
+	}
+	return nil, err
+}
+
+// JWKS publica as chaves públicas não aposentadas, inclusive as agendadas.
+func (kr *Keyring) JWKS() JWKS {
+	now := kr.now()
+	jwks := JWKS{Keys: []JWK{}}
+	for i := range kr.entries {
+		entry := &kr.entries[i]
+		if entry.retired(now) {
+			continue
+		}
+		if maker, ok := entry.Maker.(publicKeyMaker); ok {
+			if jwk, ok := maker.publicJWK(); ok {
+				jwks.Keys = append(jwks.Keys, jwk)
+			}
+		}
+	}
+	return jwks
+}
+
+// tokenKeyID lê o kid sem verificar o token: do rodapé em PASETO e do
+// cabeçalho em JWT. Retorna "" se o token não tiver kid.
+func tokenKeyID(token string) (string, error) {
+	if strings.HasPrefix(token, "v2.") {
+		var f footer
+		if err := paseto.ParseFooter(token, &f); err != nil {
+			return "", err
+		}
+		return f.KeyID, nil
+	}
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		return "", err
+	}
+	keyID, _ := parsed.Header["kid"].(string)
+	return keyID, nil
+}
@@ -0,0 +1,169 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"api--sigacore-gateway/internal/util"
+)
+
+func newTestPasetoMaker(t *testing.T, key string) Maker {
+	t.Helper()
+	maker, err := NewPasetoMaker(key)
+	require.NoError(t, err)
+	return maker
+}
+
+func TestKeyringRotation(t *testing.T) {
+	now := time.Now()
+	keyring, err := NewKeyring(
+		KeyringEntry{ID: "old", Maker: newTestPasetoMaker(t, _testInternalKey),
+			ActivateAt: now.Add(-48 * time.Hour), RetireAt: now.Add(time.Hour)},
+		KeyringEntry{ID: "current", Maker: newTestPasetoMaker(t, "vutsrqponmlkjihgfedcba9876543210"),
+			ActivateAt: now.Add(-time.Hour)},
+		KeyringEntry{ID: "next", Maker: newTestPasetoMaker(t, "ABCDEFGHIJKLMNOPQRSTUVWXYZ012345"),
+			ActivateAt: now.Add(24 * time.Hour)},
+	)
+	require.NoError(t, err)
+
+	active, err := keyring.Active()
+	require.NoError(t, err)
+	require.Equal(t, "current", active.ID)
+
+	current, _, err := keyring.CreateToken("alice", time.Hour)
+	require.NoError(t, err)
+	keyID, err := tokenKeyID(current)
+	require.NoError(t, err)
+	require.Equal(t, "current", keyID)
+
+	// Um token da chave antiga continua válido até ela ser aposentada
+	keyring.now = func() time.Time { return now.Add(-24 * time.Hour) }
+	old, _, err := keyring.CreateToken("alice", 48*time.Hour)
+	require.NoError(t, err)
+	keyring.now = time.Now
+	verified, err := keyring.VerifyToken(old)
+	require.NoError(t, err)
+	require.Equal(t, "alice", verified.Username)
+
+	// Depois da aposentadoria, a chave antiga é recusada e a próxima assina
+	keyring.now = func() time.Time { return now.Add(25 * time.Hour) }
+	_, err = keyring.VerifyToken(old)
+	require.ErrorIs(t, err, ErrTokenInvalid)
+	active, err = keyring.Active()
+	require.NoError(t, err)
+	require.Equal(t, "next", active.ID)
+	_, err = keyring.VerifyToken(current)
+	require.NoError(t, err)
+
+	_, err = keyring.VerifyToken("v2.local.garbage.!!!")
+	require.ErrorIs(t, err, ErrTokenMalformed)
+}
+
+func TestKeyringLegacyTokens(t *testing.T) {
+	legacy := newTestPasetoMaker(t, _testInternalKey)
+	legacyToken, _, err := legacy.CreateToken("alice", time.Minute)
+	require.NoError(t, err)
+
+	keyring, err := NewKeyring(
+		KeyringEntry{ID: "legacy", Maker: newTestPasetoMaker(t, _testInternalKey)},
+		KeyringEntry{ID: "current", Maker: newTestPasetoMaker(t, "vutsrqponmlkjihgfedcba9876543210"),
+			ActivateAt: time.Now().Add(-time.Minute)},
+	)
+	require.NoError(t, err)
+
+	// Tokens sem kid, emitidos antes do keyring, são verificados com cada chave
+	verified, err := keyring.VerifyToken(legacyToken)
+	require.NoError(t, err)
+	require.Equal(t, "alice", verified.Username)
+
+	other := newTestPasetoMaker(t, "ABCDEFGHIJKLMNOPQRSTUVWXYZ012345")
+	forged, _, err := other.CreateToken("mallory", time.Minute)
+	require.NoError(t, err)
+	_, err = keyring.VerifyToken(forged)
+	require.ErrorIs(t, err, ErrTokenInvalid)
+}
+
+func TestKeyringValidation(t *testing.T) {
+	_, err := NewKeyring()
+	require.Error(t, err)
+
+	_, err = NewKeyring(
+		KeyringEntry{ID: "a", Maker: newTestPasetoMaker(t, _testInternalKey)},
+		KeyringEntry{ID: "a", Maker: newTestPasetoMaker(t, _testInternalKey)},
+	)
+	require.Error(t, err)
+
+	internal, err := NewInternalMaker(_testInternalKey, "docs")
+	require.NoError(t, err)
+	_, err = NewKeyring(KeyringEntry{ID: "a", Maker: internal})
+	require.Error(t, err)
+
+	keyring, err := NewKeyring(KeyringEntry{ID: "future", Maker: newTestPasetoMaker(t, _testInternalKey),
+		ActivateAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	_, _, err = keyring.CreateToken("alice", time.Minute)
+	require.ErrorIs(t, err, ErrNoActiveKey)
+}
+
+func TestKeyringJWKS(t *testing.T) {
+	_, current, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, retired, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	currentMaker, err := NewEdDSAJWTMaker(current, nil)
+	require.NoError(t, err)
+	retiredMaker, err := NewEdDSAJWTMaker(retired, nil)
+	require.NoError(t, err)
+
+	keyring, err := NewKeyring(
+		KeyringEntry{ID: "retired", Maker: retiredMaker,
+			ActivateAt: time.Now().Add(-2 * time.Hour), RetireAt: time.Now().Add(-time.Hour)},
+		KeyringEntry{ID: "current", Maker: currentMaker, ActivateAt: time.Now().Add(-2 * time.Hour)},
+	)
+	require.NoError(t, err)
+
+	jwks := keyring.JWKS()
+	require.Len(t, jwks.Keys, 1)
+	require.Equal(t, "current", jwks.Keys[0].KeyID)
+	require.Equal(t, "OKP", jwks.Keys[0].KeyType)
+	require.Equal(t, "EdDSA", jwks.Keys[0].Algorithm)
+
+	signed, _, err := keyring.CreateToken("alice", time.Minute)
+	require.NoError(t, err)
+	keyID, err := tokenKeyID(signed)
+	require.NoError(t, err)
+	require.Equal(t, "current", keyID)
+	_, err = keyring.VerifyToken(signed)
+	require.NoError(t, err)
+}
+
+func TestLoadKeyring(t *testing.T) {
+	t.Setenv("TEST_TOKEN_KEY_NEXT", "vutsrqponmlkjihgfedcba9876543210")
+	path := filepath.Join(t.TempDir(), "keyring.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+keys:
+  - id: "2026-01"
+    symmetric_key: "`+_testInternalKey+`"
+    activate_at: 2026-01-01T00:00:00Z
+  - id: "2026-07"
+    symmetric_key: "${TEST_TOKEN_KEY_NEXT}"
+    activate_at: 2026-07-01T00:00:00Z
+`), 0o600))
+
+	keyring, err := LoadKeyring(path, util.Config{TokenType: util.TokenTypePaseto})
+	require.NoError(t, err)
+	keyring.now = func() time.Time { return time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC) }
+	active, err := keyring.Active()
+	require.NoError(t, err)
+	require.Equal(t, "2026-07", active.ID)
+
+	_, err = LoadKeyring(path, util.Config{TokenType: util.TokenTypePasetoPublic})
+	require.Error(t, err)
+}
@@ -0,0 +1,129 @@
+package token
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrInvalidKey = errors.New("invalid key")
+
+// LoadEd25519PrivateKey lê uma chave privada Ed25519 de um arquivo PEM
+// (PKCS #8, "PRIVATE KEY") ou hex (seed de 32 bytes ou chave de 64 bytes).
+func LoadEd25519PrivateKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("LoadEd25519PrivateKey: %w", err)
+	}
+
+	key, err := ParseEd25519PrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("LoadEd25519PrivateKey: %s: %w", path, err)
+	}
+	return key, nil
+}
+
+// LoadEd25519PublicKey lê uma chave pública Ed25519 de um arquivo PEM
+// (PKIX, "PUBLIC KEY") ou hex (32 bytes).
+func LoadEd25519PublicKey(path string) (ed25519.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("LoadEd25519PublicKey: %w", err)
+	}
+
+	key, err := ParseEd25519PublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("LoadEd25519PublicKey: %s: %w", path, err)
+	}
+	return key, nil
+}
+
+// ParseEd25519PrivateKey interpreta uma chave privada Ed25519 em PEM ou hex.
+func ParseEd25519PrivateKey(data []byte) (ed25519.PrivateKey, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+		}
+		key, ok := parsed.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%w: not an Ed25519 private key", ErrInvalidKey)
+		}
+		return key, nil
+	}
+
+	raw, err := hex.DecodeString(string(bytes.TrimSpace(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	switch len(raw) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(raw), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(raw), nil
+	default:
+		return nil, fmt.Errorf("%w: Ed25519 private key must have %d or %d bytes, got %d",
+			ErrInvalidKey, ed25519.SeedSize, ed25519.PrivateKeySize, len(raw))
+	}
+}
+
+// ParseEd25519PublicKey interpreta uma chave pública Ed25519 em PEM ou hex.
+func ParseEd25519PublicKey(data []byte) (ed25519.PublicKey, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+		}
+		key, ok := parsed.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("%w: not an Ed25519 public key", ErrInvalidKey)
+		}
+		return key, nil
+	}
+
+	raw, err := hex.DecodeString(string(bytes.TrimSpace(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%w: Ed25519 public key must have %d bytes, got %d",
+			ErrInvalidKey, ed25519.PublicKeySize, len(raw))
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// LoadRSAPrivateKey lê uma chave privada RSA de um arquivo PEM (PKCS #1 ou #8).
+func LoadRSAPrivateKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("LoadRSAPrivateKey: %w", err)
+	}
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("LoadRSAPrivateKey: %s: %w: %v", path, ErrInvalidKey, err)
+	}
+	return key, nil
+}
+
+// LoadRSAPublicKey lê uma chave pública RSA de um arquivo PEM (PKIX ou PKCS #1).
+func LoadRSAPublicKey(path string) (*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("LoadRSAPublicKey: %w", err)
+	}
+
+	key, err := jwt.ParseRSAPublicKeyFromPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("LoadRSAPublicKey: %s: %w: %v", path, ErrInvalidKey, err)
+	}
+	return key, nil
+}
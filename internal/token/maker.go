@@ -3,6 +3,6 @@ package token
 import "time"
 
 type Maker interface {
-	CreateToken(username string, duration time.Duration) (string, *Payload, error)
+	CreateToken(username string, duration time.Duration, opts ...PayloadOption) (string, *Payload, error)
 	VerifyToken(token string) (*Payload, error)
 }
@@ -11,6 +11,7 @@ import (
 type PasetoMaker struct {
 	paseto       *paseto.V2
 	symmetricKey []byte
+	keyID        string
 }
 
 func NewPasetoMaker(symmetricKey string) (Maker, error) {
@@ -23,13 +24,13 @@ func NewPasetoMaker(symmetricKey string) (Maker, error) {
 	}, nil
 }
 
-func (pm *PasetoMaker) CreateToken(username string, duration time.Duration) (string, *Payload, error) {
-	payload, err := NewPayload(username, duration)
+func (pm *PasetoMaker) CreateToken(username string, duration time.Duration, opts ...PayloadOption) (string, *Payload, error) {
+	payload, err := NewPayload(username, duration, opts...)
 	if err != nil {
 		return "", nil, fmt.Errorf("CreateToken: %w", err)
 	}
 
-	token, err := pm.paseto.Encrypt(pm.symmetricKey, payload, nil)
+	token, err := pm.paseto.Encrypt(pm.symmetricKey, payload, newFooter(pm.keyID))
 	if err != nil {
 		return "", nil, fmt.Errorf("CreateToken: %w", err)
 	}
@@ -50,3 +51,7 @@ func (pm *PasetoMaker) VerifyToken(token string) (*Payload, error) {
 
 	return payload, nil
 }
+
+func (pm *PasetoMaker) setKeyID(keyID string) {
+	pm.keyID = keyID
+}
@@ -3,6 +3,7 @@ package token
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -15,53 +16,125 @@ var (
 )
 
 type Payload struct {
-	ID        uuid.UUID `json:"id"`
-	Username  string    `json:"username"`
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+	// SessionID liga um access token à sessão (refresh token) que o originou
+	SessionID uuid.UUID `json:"session_id"`
+	// Audience identifica para quem o token foi emitido: TOKEN_AUDIENCE em
+	// tokens de usuário e o serviço de destino em tokens internos
+	Audience string `json:"audience,omitempty"`
+	// Issuer identifica quem emitiu o token (claim iss em JWT)
+	Issuer string `json:"issuer,omitempty"`
+	// Roles e Permissions são os papéis do usuário no login e a união das
+	// permissões que eles concedem
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	// Scopes são os escopos OAuth2 concedidos ao token
+	Scopes    []string  `json:"scopes,omitempty"`
 	IssuedAt  time.Time `json:"issued_at"`
 	ExpiredAt time.Time `json:"expires"`
 }
 
+// PayloadOption preenche campos opcionais do payload ao criar um token.
+type PayloadOption func(*Payload)
+
+// WithAudience restringe o token ao serviço audience.
+func WithAudience(audience string) PayloadOption {
+	return func(p *Payload) {
+		p.Audience = audience
+	}
+}
+
+// WithIssuer registra quem emitiu o token.
+func WithIssuer(issuer string) PayloadOption {
+	return func(p *Payload) {
+		p.Issuer = issuer
+	}
+}
+
+// WithRoles registra os papéis do usuário e as permissões concedidas por eles.
+func WithRoles(roles, permissions []string) PayloadOption {
+	return func(p *Payload) {
+		p.Roles = roles
+		p.Permissions = permissions
+	}
+}
+
+// WithScopes concede os escopos ao token.
+func WithScopes(scopes ...string) PayloadOption {
+	return func(p *Payload) {
+		p.Scopes = scopes
+	}
+}
+
+// WithSessionID associa o token a uma sessão.
+func WithSessionID(sessionID uuid.UUID) PayloadOption {
+	return func(p *Payload) {
+		p.SessionID = sessionID
+	}
+}
+
+// PermissionAll concede todas as permissões.
+const PermissionAll = "*"
+
+// HasRole diz se o usuário tem o papel role.
+func (p *Payload) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// HasPermission diz se algum papel do usuário concede permission.
+func (p *Payload) HasPermission(permission string) bool {
+	return slices.Contains(p.Permissions, permission) || slices.Contains(p.Permissions, PermissionAll)
+}
+
+// HasScope diz se o token recebeu o escopo scope.
+func (p *Payload) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
 func (p *Payload) GetExpirationTime() (*jwt.NumericDate, error) {
-	//TODO implement me
-	return &jwt.NumericDate{Time: p.ExpiredAt}, nil
+	return jwt.NewNumericDate(p.ExpiredAt), nil
 }
 
 func (p *Payload) GetIssuedAt() (*jwt.NumericDate, error) {
-	//TODO implement me
-	return &jwt.NumericDate{Time: p.IssuedAt}, nil
+	return jwt.NewNumericDate(p.IssuedAt), nil
 }
 
 func (p *Payload) GetNotBefore() (*jwt.NumericDate, error) {
-	//TODO implement me
-	return &jwt.NumericDate{Time: p.IssuedAt}, nil
+	return jwt.NewNumericDate(p.IssuedAt), nil
 }
 
 func (p *Payload) GetIssuer() (string, error) {
-	//TODO implement me
-	return "", nil
+	return p.Issuer, nil
 }
 
+// GetSubject retorna o usuário dono do token.
 func (p *Payload) GetSubject() (string, error) {
-	//TODO implement me
-	return "", nil
+	return p.Username, nil
 }
 
 func (p *Payload) GetAudience() (jwt.ClaimStrings, error) {
-	//TODO implement me
-	return jwt.ClaimStrings{}, nil
+	if p.Audience == "" {
+		return jwt.ClaimStrings{}, nil
+	}
+	return jwt.ClaimStrings{p.Audience}, nil
 }
 
-func NewPayload(username string, duration time.Duration) (*Payload, error) {
+func NewPayload(username string, duration time.Duration, opts ...PayloadOption) (*Payload, error) {
 	tokenID, err := uuid.NewUUID()
 	if err != nil {
 		return nil, fmt.Errorf("NewPayload: %v", err)
 	}
-	return &Payload{
+	payload := &Payload{
 		ID:        tokenID,
 		Username:  username,
 		IssuedAt:  time.Now(),
 		ExpiredAt: time.Now().Add(duration),
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(payload)
+	}
+	return payload, nil
 }
 
 func (p *Payload) Valid() error {
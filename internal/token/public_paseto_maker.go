@@ -0,0 +1,100 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/o1egl/paseto"
+)
+
+var ErrVerifyOnly = errors.New("maker has no private key and can only verify tokens")
+
+// PublicPasetoMaker emite tokens PASETO v2.public, assinados com Ed25519. Só
+// quem emite tokens (o serviço de auth) precisa da chave privada; os demais
+// serviços verificam com a chave pública e não conseguem emitir tokens.
+type PublicPasetoMaker struct {
+	paseto     *paseto.V2
+	privateKey ed25519.PrivateKey
+	publicKey  ed25519.PublicKey
+	keyID      string
+}
+
+// NewPublicPasetoMaker cria o maker. privateKey pode ser nil para um maker que
+// só verifica; publicKey pode ser nil se privateKey for informada.
+func NewPublicPasetoMaker(privateKey ed25519.PrivateKey, publicKey ed25519.PublicKey) (Maker, error) {
+	if privateKey == nil && publicKey == nil {
+		return nil, fmt.Errorf("NewPublicPasetoMaker: a private or public key is required")
+	}
+	if privateKey != nil {
+		if len(privateKey) != ed25519.PrivateKeySize {
+			return nil, fmt.Errorf("NewPublicPasetoMaker: invalid private key size")
+		}
+		derived := privateKey.Public().(ed25519.PublicKey)
+		if publicKey != nil && !publicKey.Equal(derived) {
+			return nil, fmt.Errorf("NewPublicPasetoMaker: public key does not match private key")
+		}
+		publicKey = derived
+	}
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("NewPublicPasetoMaker: invalid public key size")
+	}
+
+	return &PublicPasetoMaker{
+		paseto:     paseto.NewV2(),
+		privateKey: privateKey,
+		publicKey:  publicKey,
+	}, nil
+}
+
+func (pm *PublicPasetoMaker) CreateToken(username string, duration time.Duration, opts ...PayloadOption) (string, *Payload, error) {
+	if pm.privateKey == nil {
+		return "", nil, fmt.Errorf("CreateToken: %w", ErrVerifyOnly)
+	}
+
+	payload, err := NewPayload(username, duration, opts...)
+	if err != nil {
+		return "", nil, fmt.Errorf("CreateToken: %w", err)
+	}
+
+	token, err := pm.paseto.Sign(pm.privateKey, payload, newFooter(pm.keyID))
+	if err != nil {
+		return "", nil, fmt.Errorf("CreateToken: %w", err)
+	}
+	return token, payload, nil
+}
+
+func (pm *PublicPasetoMaker) VerifyToken(token string) (*Payload, error) {
+	payload := &Payload{}
+	err := pm.paseto.Verify(token, pm.publicKey, payload, nil)
+	if err != nil {
+		return nil, &TokenError{Op: "verify", Err: ErrTokenInvalid}
+	}
+
+	err = payload.Valid()
+	if err != nil {
+		return nil, &TokenError{Op: "validate", Err: ErrTokenExpired}
+	}
+
+	return payload, nil
+}
+
+// PublicKey retorna a chave pública usada na verificação.
+func (pm *PublicPasetoMaker) PublicKey() ed25519.PublicKey {
+	return pm.publicKey
+}
+
+// JWKS publica a chave pública de verificação.
+func (pm *PublicPasetoMaker) JWKS() JWKS {
+	jwk, _ := pm.publicJWK()
+	return JWKS{Keys: []JWK{jwk}}
+}
+
+func (pm *PublicPasetoMaker) publicJWK() (JWK, bool) {
+	return newEd25519JWK(pm.keyID, "", pm.publicKey), true
+}
+
+func (pm *PublicPasetoMaker) setKeyID(keyID string) {
+	pm.keyID = keyID
+}
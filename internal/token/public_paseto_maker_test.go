@@ -0,0 +1,94 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublicPasetoMaker(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	issuer, err := NewPublicPasetoMaker(privateKey, nil)
+	require.NoError(t, err)
+	verifier, err := NewPublicPasetoMaker(nil, publicKey)
+	require.NoError(t, err)
+
+	signed, payload, err := issuer.CreateToken("alice", time.Minute)
+	require.NoError(t, err)
+
+	verified, err := verifier.VerifyToken(signed)
+	require.NoError(t, err)
+	require.Equal(t, payload.ID, verified.ID)
+	require.Equal(t, "alice", verified.Username)
+
+	// Quem só tem a chave pública não emite tokens
+	_, _, err = verifier.CreateToken("mallory", time.Minute)
+	require.ErrorIs(t, err, ErrVerifyOnly)
+
+	// Tokens assinados com outra chave são recusados
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	other, err := NewPublicPasetoMaker(otherKey, nil)
+	require.NoError(t, err)
+	forged, _, err := other.CreateToken("mallory", time.Minute)
+	require.NoError(t, err)
+	_, err = verifier.VerifyToken(forged)
+	require.ErrorIs(t, err, ErrTokenInvalid)
+
+	expired, _, err := issuer.CreateToken("alice", -time.Minute)
+	require.NoError(t, err)
+	_, err = verifier.VerifyToken(expired)
+	require.ErrorIs(t, err, ErrTokenExpired)
+
+	_, err = NewPublicPasetoMaker(privateKey, otherKey.Public().(ed25519.PublicKey))
+	require.Error(t, err)
+}
+
+func TestLoadEd25519Keys(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	dir := t.TempDir()
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+
+	files := map[string][]byte{
+		"private.pem": pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}),
+		"public.pem":  pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}),
+		"seed.hex":    []byte(hex.EncodeToString(privateKey.Seed()) + "\n"),
+		"private.hex": []byte(hex.EncodeToString(privateKey)),
+		"public.hex":  []byte(hex.EncodeToString(publicKey)),
+		"bad.hex":     []byte("abcd"),
+	}
+	for name, data := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), data, 0o600))
+	}
+
+	for _, name := range []string{"private.pem", "seed.hex", "private.hex"} {
+		key, err := LoadEd25519PrivateKey(filepath.Join(dir, name))
+		require.NoError(t, err, name)
+		require.True(t, privateKey.Equal(key), name)
+	}
+	for _, name := range []string{"public.pem", "public.hex"} {
+		key, err := LoadEd25519PublicKey(filepath.Join(dir, name))
+		require.NoError(t, err, name)
+		require.True(t, publicKey.Equal(key), name)
+	}
+
+	_, err = LoadEd25519PrivateKey(filepath.Join(dir, "bad.hex"))
+	require.ErrorIs(t, err, ErrInvalidKey)
+	_, err = LoadEd25519PublicKey(filepath.Join(dir, "private.pem"))
+	require.ErrorIs(t, err, ErrInvalidKey)
+}
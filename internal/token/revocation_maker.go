@@ -0,0 +1,50 @@
+package token
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RevocationList diz se um token (ou a sessão que o originou) foi revogado
+// antes de expirar.
+type RevocationList interface {
+	IsRevoked(id uuid.UUID) bool
+}
+
+// RevocationMaker recusa tokens revogados. A consulta usa o ID do token e o
+// SessionID: revogar uma sessão derruba o refresh token (cujo ID é o da
+// sessão) e todos os access tokens emitidos a partir dele.
+type RevocationMaker struct {
+	maker Maker
+	list  RevocationList
+}
+
+func NewRevocationMaker(maker Maker, list RevocationList) *RevocationMaker {
+	return &RevocationMaker{maker: maker, list: list}
+}
+
+func (rm *RevocationMaker) CreateToken(username string, duration time.Duration, opts ...PayloadOption) (string, *Payload, error) {
+	return rm.maker.CreateToken(username, duration, opts...)
+}
+
+func (rm *RevocationMaker) VerifyToken(token string) (*Payload, error) {
+	payload, err := rm.maker.VerifyToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if rm.list.IsRevoked(payload.ID) ||
+		(payload.SessionID != uuid.Nil && rm.list.IsRevoked(payload.SessionID)) {
+		return nil, &TokenError{Op: "revocation", Err: ErrTokenRevoked}
+	}
+	return payload, nil
+}
+
+// JWKS repassa as chaves públicas do maker envolvido, se houver.
+func (rm *RevocationMaker) JWKS() JWKS {
+	if provider, ok := rm.maker.(JWKSProvider); ok {
+		return provider.JWKS()
+	}
+	return JWKS{Keys: []JWK{}}
+}
@@ -0,0 +1,42 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type revokedSet map[uuid.UUID]bool
+
+func (s revokedSet) IsRevoked(id uuid.UUID) bool {
+	return s[id]
+}
+
+func TestRevocationMaker(t *testing.T) {
+	revoked := revokedSet{}
+	maker := NewRevocationMaker(newTestPasetoMaker(t, _testInternalKey), revoked)
+
+	sessionID := uuid.New()
+	signed, payload, err := maker.CreateToken("alice", time.Minute, WithSessionID(sessionID))
+	require.NoError(t, err)
+
+	_, err = maker.VerifyToken(signed)
+	require.NoError(t, err)
+
+	// Revogar o token
+	revoked[payload.ID] = true
+	_, err = maker.VerifyToken(signed)
+	require.ErrorIs(t, err, ErrTokenRevoked)
+
+	// Revogar a sessão derruba os access tokens emitidos por ela
+	other, _, err := maker.CreateToken("alice", time.Minute, WithSessionID(sessionID))
+	require.NoError(t, err)
+	_, err = maker.VerifyToken(other)
+	require.NoError(t, err)
+
+	revoked[sessionID] = true
+	_, err = maker.VerifyToken(other)
+	require.ErrorIs(t, err, ErrTokenRevoked)
+}
@@ -16,14 +16,94 @@ type Config struct {
 	AuthServerAddress          string        `mapstructure:"AUTH_SERVER_ADDRESS"`
 	GatewayServerAddress       string        `mapstructure:"GATEWAY_SERVER_ADDRESS"`
 	TokenSymmetricKey          string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	TokenType                  string        `mapstructure:"TOKEN_TYPE"`
+	TokenPrivateKeyFile        string        `mapstructure:"TOKEN_PRIVATE_KEY_FILE"`
+	TokenPublicKeyFile         string        `mapstructure:"TOKEN_PUBLIC_KEY_FILE"`
+	TokenJWTAlgorithm          string        `mapstructure:"TOKEN_JWT_ALGORITHM"`
+	TokenKeyringFile           string        `mapstructure:"TOKEN_KEYRING_FILE"`
+	TokenIssuer                string        `mapstructure:"TOKEN_ISSUER"`
+	TokenAudience              string        `mapstructure:"TOKEN_AUDIENCE"`
+	TokenScopes                []string      `mapstructure:"TOKEN_SCOPES"`
 	AccessTokenDuration        time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
 	RefreshTokenDuration       time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	TokenRevocationRefresh     time.Duration `mapstructure:"TOKEN_REVOCATION_REFRESH_INTERVAL"`
+	SessionCleanupInterval     time.Duration `mapstructure:"SESSION_CLEANUP_INTERVAL"`
+	SessionCleanupGracePeriod  time.Duration `mapstructure:"SESSION_CLEANUP_GRACE_PERIOD"`
+	SessionCleanupBatchSize    int           `mapstructure:"SESSION_CLEANUP_BATCH_SIZE"`
+	SessionMaxPerUser          int           `mapstructure:"SESSION_MAX_PER_USER"`
+	SessionLimitPolicy         string        `mapstructure:"SESSION_LIMIT_POLICY"`
+	LoginLockoutThreshold      int           `mapstructure:"LOGIN_LOCKOUT_THRESHOLD"`
+	LoginLockoutIPThreshold    int           `mapstructure:"LOGIN_LOCKOUT_IP_THRESHOLD"`
+	LoginLockoutBase           time.Duration `mapstructure:"LOGIN_LOCKOUT_BASE"`
+	LoginLockoutMax            time.Duration `mapstructure:"LOGIN_LOCKOUT_MAX"`
+	LoginFailureWindow         time.Duration `mapstructure:"LOGIN_FAILURE_WINDOW"`
+	MFAIssuer                  string        `mapstructure:"MFA_ISSUER"`
+	MFAChallengeDuration       time.Duration `mapstructure:"MFA_CHALLENGE_DURATION"`
+	MFARecoveryCodes           int           `mapstructure:"MFA_RECOVERY_CODES"`
+	MFASecretKey               string        `mapstructure:"MFA_SECRET_KEY"`
 	AllowedIPs                 []string      `mapstructure:"ALLOWED_IPS"`
 	UserServiceAddress         string        `mapstructure:"USER_SERVICE_ADDRESS"`
 	DocServiceAddress          string        `mapstructure:"DOC_SERVICE_ADDRESS"`
 	NotificationServiceAddress string        `mapstructure:"NOTIFICATION_SERVICE_ADDRESS"`
+	GatewayRoutesFile          string        `mapstructure:"GATEWAY_ROUTES_FILE"`
+	RetryBudgetRatio           float64       `mapstructure:"RETRY_BUDGET_RATIO"`
+	RetryBudgetMinPerSecond    int           `mapstructure:"RETRY_BUDGET_MIN_PER_SECOND"`
+	RateLimitPerSecond         float64       `mapstructure:"RATE_LIMIT_PER_SECOND"`
+	RateLimitBurst             int           `mapstructure:"RATE_LIMIT_BURST"`
+	RateLimitPerIP             float64       `mapstructure:"RATE_LIMIT_PER_IP"`
+	RateLimitPerUser           float64       `mapstructure:"RATE_LIMIT_PER_USER"`
+	RateLimitBackend           string        `mapstructure:"RATE_LIMIT_BACKEND"`
+	IPFilterMode               string        `mapstructure:"IP_FILTER_MODE"`
+	DeniedIPs                  []string      `mapstructure:"DENIED_IPS"`
+	TrustedProxies             []string      `mapstructure:"TRUSTED_PROXIES"`
+	CORSAllowedOrigins         []string      `mapstructure:"CORS_ALLOWED_ORIGINS"`
+	CORSAllowedMethods         []string      `mapstructure:"CORS_ALLOWED_METHODS"`
+	CORSAllowedHeaders         []string      `mapstructure:"CORS_ALLOWED_HEADERS"`
+	CORSExposedHeaders         []string      `mapstructure:"CORS_EXPOSED_HEADERS"`
+	CORSAllowCredentials       bool          `mapstructure:"CORS_ALLOW_CREDENTIALS"`
+	CORSMaxAge                 time.Duration `mapstructure:"CORS_MAX_AGE"`
+	GatewayIdentityKey         string        `mapstructure:"GATEWAY_IDENTITY_KEY"`
+	GatewayIdentityMaxAge      time.Duration `mapstructure:"GATEWAY_IDENTITY_MAX_AGE"`
+	InternalTokenSymmetricKey  string        `mapstructure:"INTERNAL_TOKEN_SYMMETRIC_KEY"`
+	InternalTokenDuration      time.Duration `mapstructure:"INTERNAL_TOKEN_DURATION"`
 }
 
+// Tipos de token: paseto (v2.local, chave simétrica), paseto_public
+// (v2.public, Ed25519) ou jwt (algoritmo em TOKEN_JWT_ALGORITHM)
+const (
+	TokenTypePaseto       = "paseto"
+	TokenTypePasetoPublic = "paseto_public"
+	TokenTypeJWT          = "jwt"
+)
+
+// Algoritmos de assinatura aceitos com TOKEN_TYPE=jwt: HS256 usa
+// TOKEN_SYMMETRIC_KEY; RS256 e EdDSA, os arquivos de chave
+const (
+	JWTAlgorithmHS256 = "HS256"
+	JWTAlgorithmRS256 = "RS256"
+	JWTAlgorithmEdDSA = "EdDSA"
+)
+
+// Backends do rate limiter do gateway
+const (
+	RateLimitBackendMemory   = "memory"
+	RateLimitBackendPostgres = "postgres"
+)
+
+// Políticas ao atingir SESSION_MAX_PER_USER: reject recusa o novo login e
+// evict_oldest encerra a sessão usada há mais tempo
+const (
+	SessionLimitReject      = "reject"
+	SessionLimitEvictOldest = "evict_oldest"
+)
+
+// Modos do filtro de IPs do gateway: allowlist só deixa passar ALLOWED_IPS e
+// denylist bloqueia apenas DENIED_IPS
+const (
+	IPFilterAllowlist = "allowlist"
+	IPFilterDenylist  = "denylist"
+)
+
 // Constantes para ambientes
 const (
 	EnvDevelopment = "development"
@@ -66,13 +146,15 @@ func LoadConfig() (config Config, err error) {
 		return config, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
-	// Processar AllowedIPs (separados por vírgula)
-	if ipsStr := viper.GetString("ALLOWED_IPS"); ipsStr != "" {
-		config.AllowedIPs = strings.Split(ipsStr, ",")
-		for i, ip := range config.AllowedIPs {
-			config.AllowedIPs[i] = strings.TrimSpace(ip)
-		}
-	}
+	// Processar listas de IPs e redes (separados por vírgula)
+	config.AllowedIPs = splitList(viper.GetString("ALLOWED_IPS"))
+	config.DeniedIPs = splitList(viper.GetString("DENIED_IPS"))
+	config.TrustedProxies = splitList(viper.GetString("TRUSTED_PROXIES"))
+	config.TokenScopes = splitList(viper.GetString("TOKEN_SCOPES"))
+	config.CORSAllowedOrigins = splitList(viper.GetString("CORS_ALLOWED_ORIGINS"))
+	config.CORSAllowedMethods = splitList(viper.GetString("CORS_ALLOWED_METHODS"))
+	config.CORSAllowedHeaders = splitList(viper.GetString("CORS_ALLOWED_HEADERS"))
+	config.CORSExposedHeaders = splitList(viper.GetString("CORS_EXPOSED_HEADERS"))
 
 	// Validar configuração
 	if err := validateConfig(&config); err != nil {
@@ -89,7 +171,42 @@ func setDefaults() {
 	viper.SetDefault("GATEWAY_SERVER_ADDRESS", "localhost:8081")
 	viper.SetDefault("ACCESS_TOKEN_DURATION", "15m")
 	viper.SetDefault("REFRESH_TOKEN_DURATION", "24h")
+	viper.SetDefault("TOKEN_TYPE", TokenTypePaseto)
+	viper.SetDefault("TOKEN_JWT_ALGORITHM", JWTAlgorithmHS256)
+	viper.SetDefault("TOKEN_ISSUER", "sigacore-auth")
+	viper.SetDefault("TOKEN_AUDIENCE", "sigacore")
+	viper.SetDefault("TOKEN_REVOCATION_REFRESH_INTERVAL", "30s")
+	viper.SetDefault("SESSION_CLEANUP_INTERVAL", "1h")
+	viper.SetDefault("SESSION_CLEANUP_GRACE_PERIOD", "168h")
+	viper.SetDefault("SESSION_CLEANUP_BATCH_SIZE", 1000)
+	viper.SetDefault("SESSION_MAX_PER_USER", 0)
+	viper.SetDefault("SESSION_LIMIT_POLICY", SessionLimitReject)
+	viper.SetDefault("LOGIN_LOCKOUT_THRESHOLD", 5)
+	viper.SetDefault("LOGIN_LOCKOUT_IP_THRESHOLD", 20)
+	viper.SetDefault("LOGIN_LOCKOUT_BASE", "30s")
+	viper.SetDefault("LOGIN_LOCKOUT_MAX", "1h")
+	viper.SetDefault("LOGIN_FAILURE_WINDOW", "24h")
+	viper.SetDefault("MFA_ISSUER", "SigaCore")
+	viper.SetDefault("MFA_CHALLENGE_DURATION", "5m")
+	viper.SetDefault("MFA_RECOVERY_CODES", 10)
 	viper.SetDefault("ALLOWED_IPS", "127.0.0.1")
+	viper.SetDefault("IP_FILTER_MODE", IPFilterAllowlist)
+	viper.SetDefault("CORS_ALLOWED_ORIGINS", "http://localhost:3000")
+	viper.SetDefault("CORS_ALLOWED_METHODS", "GET,POST,PUT,PATCH,DELETE,OPTIONS")
+	viper.SetDefault("CORS_ALLOWED_HEADERS", "Origin,Content-Type,Content-Length,Accept-Encoding,X-CSRF-Token,Authorization")
+	viper.SetDefault("CORS_EXPOSED_HEADERS", "RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset,Retry-After")
+	viper.SetDefault("CORS_ALLOW_CREDENTIALS", true)
+	viper.SetDefault("CORS_MAX_AGE", "24h")
+	viper.SetDefault("GATEWAY_IDENTITY_MAX_AGE", "5m")
+	viper.SetDefault("INTERNAL_TOKEN_DURATION", "30s")
+	viper.SetDefault("GATEWAY_ROUTES_FILE", "routes.yaml")
+	viper.SetDefault("RETRY_BUDGET_RATIO", 0.2)
+	viper.SetDefault("RETRY_BUDGET_MIN_PER_SECOND", 10)
+	viper.SetDefault("RATE_LIMIT_PER_SECOND", 5)
+	viper.SetDefault("RATE_LIMIT_BURST", 10)
+	viper.SetDefault("RATE_LIMIT_PER_IP", 10)
+	viper.SetDefault("RATE_LIMIT_PER_USER", 100)
+	viper.SetDefault("RATE_LIMIT_BACKEND", RateLimitBackendMemory)
 }
 
 // validateConfig valida toda a configuração
@@ -99,8 +216,18 @@ func validateConfig(config *Config) error {
 		return err
 	}
 
-	// Validar chave simétrica
-	if err := validateSymmetricKey(config.TokenSymmetricKey, config.Environment); err != nil {
+	// Validar chaves dos tokens
+	if err := validateTokenKeys(config); err != nil {
+		return err
+	}
+
+	// Validar chave dos cabeçalhos de identidade do gateway
+	if err := validateIdentityKey(config); err != nil {
+		return err
+	}
+
+	// Validar chave dos tokens internos (token exchange)
+	if err := validateInternalTokenKey(config); err != nil {
 		return err
 	}
 
@@ -115,7 +242,42 @@ func validateConfig(config *Config) error {
 	}
 
 	// Validar IPs permitidos
-	if err := validateAllowedIPs(config.AllowedIPs, config.Environment); err != nil {
+	if err := validateAllowedIPs(config); err != nil {
+		return err
+	}
+
+	// Validar orçamento de retries do gateway
+	if err := validateRetryBudget(config); err != nil {
+		return err
+	}
+
+	// Validar rate limiting
+	if err := validateRateLimits(config); err != nil {
+		return err
+	}
+
+	// Validar política CORS
+	if err := validateCORS(config); err != nil {
+		return err
+	}
+
+	// Validar limpeza de sessões expiradas
+	if err := validateSessionCleanup(config); err != nil {
+		return err
+	}
+
+	// Validar limite de sessões por usuário
+	if err := validateSessionLimit(config); err != nil {
+		return err
+	}
+
+	// Validar bloqueio de login por tentativas falhas
+	if err := validateLoginLockout(config); err != nil {
+		return err
+	}
+
+	// Validar segundo fator (TOTP)
+	if err := validateMFA(config); err != nil {
 		return err
 	}
 
@@ -133,6 +295,71 @@ func validateEnvironment(env string) error {
 	}
 }
 
+// validateTokenKeys valida as chaves exigidas pelo TOKEN_TYPE escolhido
+func validateTokenKeys(config *Config) error {
+	symmetric, err := tokenTypeIsSymmetric(config)
+	if err != nil {
+		return err
+	}
+
+	if config.TokenIssuer == "" || config.TokenAudience == "" {
+		return fmt.Errorf("TOKEN_ISSUER and TOKEN_AUDIENCE are required")
+	}
+	for _, scope := range config.TokenScopes {
+		if strings.ContainsAny(scope, " \t\"\\") {
+			return fmt.Errorf("TOKEN_SCOPES: invalid scope %q", scope)
+		}
+	}
+	if config.TokenRevocationRefresh <= 0 {
+		return fmt.Errorf("TOKEN_REVOCATION_REFRESH_INTERVAL must be positive")
+	}
+
+	// Com keyring, as chaves vêm de TOKEN_KEYRING_FILE e são validadas ao
+	// carregá-lo
+	if config.TokenKeyringFile != "" {
+		return nil
+	}
+	if symmetric {
+		return validateSymmetricKey(config.TokenSymmetricKey, config.Environment)
+	}
+	// O serviço de auth precisa da chave privada; quem só verifica tokens,
+	// da pública
+	return validateKeyFiles(config)
+}
+
+// tokenTypeIsSymmetric diz se TOKEN_TYPE (e TOKEN_JWT_ALGORITHM) usam chave
+// simétrica
+func tokenTypeIsSymmetric(config *Config) (bool, error) {
+	switch config.TokenType {
+	case TokenTypePaseto:
+		return true, nil
+	case TokenTypePasetoPublic:
+		return false, nil
+	case TokenTypeJWT:
+		switch config.TokenJWTAlgorithm {
+		case JWTAlgorithmHS256:
+			return true, nil
+		case JWTAlgorithmRS256, JWTAlgorithmEdDSA:
+			return false, nil
+		default:
+			return false, fmt.Errorf("TOKEN_JWT_ALGORITHM must be %q, %q or %q, got %q",
+				JWTAlgorithmHS256, JWTAlgorithmRS256, JWTAlgorithmEdDSA, config.TokenJWTAlgorithm)
+		}
+	default:
+		return false, fmt.Errorf("TOKEN_TYPE must be %q, %q or %q, got %q",
+			TokenTypePaseto, TokenTypePasetoPublic, TokenTypeJWT, config.TokenType)
+	}
+}
+
+// validateKeyFiles exige ao menos um dos arquivos de chave dos tokens
+// assinados com chave assimétrica
+func validateKeyFiles(config *Config) error {
+	if config.TokenPrivateKeyFile == "" && config.TokenPublicKeyFile == "" {
+		return fmt.Errorf("TOKEN_PRIVATE_KEY_FILE or TOKEN_PUBLIC_KEY_FILE is required for TOKEN_TYPE %s", config.TokenType)
+	}
+	return nil
+}
+
 // validateSymmetricKey valida a chave simétrica
 func validateSymmetricKey(key, environment string) error {
 	if key == "" {
@@ -158,6 +385,134 @@ func validateSymmetricKey(key, environment string) error {
 	return nil
 }
 
+// validateIdentityKey valida a chave HMAC dos cabeçalhos de identidade que o
+// gateway envia aos backends. A chave é opcional: sem ela, o gateway não envia
+// identidade e backends com GatewayIdentity recusam as requisições
+func validateIdentityKey(config *Config) error {
+	if config.GatewayIdentityMaxAge <= 0 {
+		return fmt.Errorf("GATEWAY_IDENTITY_MAX_AGE must be positive")
+	}
+	if config.GatewayIdentityKey == "" {
+		return nil
+	}
+
+	if len(config.GatewayIdentityKey) < 32 {
+		return fmt.Errorf("GATEWAY_IDENTITY_KEY must have at least 32 characters")
+	}
+	if config.GatewayIdentityKey == config.TokenSymmetricKey {
+		return fmt.Errorf("GATEWAY_IDENTITY_KEY must differ from TOKEN_SYMMETRIC_KEY")
+	}
+	if config.Environment == EnvProduction {
+		if _unsafeKeys[config.GatewayIdentityKey] || !hasGoodEntropy(config.GatewayIdentityKey) {
+			return fmt.Errorf("GATEWAY_IDENTITY_KEY is not safe for production")
+		}
+	}
+	return nil
+}
+
+// validateInternalTokenKey valida a chave dos tokens internos que o gateway
+// emite para os backends. A chave é opcional: sem ela, rotas com
+// token_exchange não sobem
+func validateInternalTokenKey(config *Config) error {
+	if config.InternalTokenDuration <= 0 {
+		return fmt.Errorf("INTERNAL_TOKEN_DURATION must be positive")
+	}
+	if config.InternalTokenSymmetricKey == "" {
+		return nil
+	}
+
+	if len(config.InternalTokenSymmetricKey) != 32 {
+		return fmt.Errorf("INTERNAL_TOKEN_SYMMETRIC_KEY must be exactly 32 characters, got %d",
+			len(config.InternalTokenSymmetricKey))
+	}
+	if config.InternalTokenSymmetricKey == config.TokenSymmetricKey {
+		return fmt.Errorf("INTERNAL_TOKEN_SYMMETRIC_KEY must differ from TOKEN_SYMMETRIC_KEY")
+	}
+	if config.Environment == EnvProduction {
+		if _unsafeKeys[config.InternalTokenSymmetricKey] || !hasGoodEntropy(config.InternalTokenSymmetricKey) {
+			return fmt.Errorf("INTERNAL_TOKEN_SYMMETRIC_KEY is not safe for production")
+		}
+	}
+	return nil
+}
+
+// validateSessionCleanup valida a limpeza periódica das sessões expiradas
+func validateSessionCleanup(config *Config) error {
+	if config.SessionCleanupInterval <= 0 {
+		return fmt.Errorf("SESSION_CLEANUP_INTERVAL must be positive")
+	}
+	if config.SessionCleanupGracePeriod < 0 {
+		return fmt.Errorf("SESSION_CLEANUP_GRACE_PERIOD must not be negative")
+	}
+	if config.SessionCleanupBatchSize <= 0 {
+		return fmt.Errorf("SESSION_CLEANUP_BATCH_SIZE must be positive")
+	}
+	return nil
+}
+
+// validateSessionLimit valida o limite de sessões ativas por usuário
+func validateSessionLimit(config *Config) error {
+	if config.SessionMaxPerUser < 0 {
+		return fmt.Errorf("SESSION_MAX_PER_USER must not be negative")
+	}
+	switch config.SessionLimitPolicy {
+	case SessionLimitReject, SessionLimitEvictOldest:
+		return nil
+	default:
+		return fmt.Errorf("SESSION_LIMIT_POLICY must be %q or %q, got %q",
+			SessionLimitReject, SessionLimitEvictOldest, config.SessionLimitPolicy)
+	}
+}
+
+// validateLoginLockout valida o bloqueio de login por tentativas falhas
+func validateLoginLockout(config *Config) error {
+	if config.LoginLockoutThreshold <= 0 || config.LoginLockoutIPThreshold <= 0 {
+		return fmt.Errorf("LOGIN_LOCKOUT_THRESHOLD and LOGIN_LOCKOUT_IP_THRESHOLD must be positive")
+	}
+	if config.LoginLockoutBase <= 0 || config.LoginLockoutMax < config.LoginLockoutBase {
+		return fmt.Errorf("LOGIN_LOCKOUT_BASE must be positive and not greater than LOGIN_LOCKOUT_MAX")
+	}
+	// Com uma janela menor que o bloqueio, a contagem recomeçaria ao fim de
+	// cada bloqueio e o tempo nunca dobraria
+	if config.LoginFailureWindow < config.LoginLockoutMax {
+		return fmt.Errorf("LOGIN_FAILURE_WINDOW must not be shorter than LOGIN_LOCKOUT_MAX")
+	}
+	return nil
+}
+
+// validateMFA valida o segundo fator TOTP
+func validateMFA(config *Config) error {
+	// O issuer vira o prefixo do rótulo no URI otpauth, separado por ":"
+	if config.MFAIssuer == "" || strings.Contains(config.MFAIssuer, ":") {
+		return fmt.Errorf("MFA_ISSUER is required and must not contain ':'")
+	}
+	if config.MFAChallengeDuration <= 0 || config.MFAChallengeDuration > 15*time.Minute {
+		return fmt.Errorf("MFA_CHALLENGE_DURATION must be between 0 and 15m")
+	}
+	if config.MFARecoveryCodes <= 0 || config.MFARecoveryCodes > 20 {
+		return fmt.Errorf("MFA_RECOVERY_CODES must be between 1 and 20")
+	}
+
+	// Chave AES-256 que cifra os segredos TOTP guardados no banco. Sem ela,
+	// o cadastro de TOTP fica desligado (MFAEnabled)
+	if !config.MFAEnabled() {
+		return nil
+	}
+	if len(config.MFASecretKey) != SecretKeySize {
+		return fmt.Errorf("MFA_SECRET_KEY must be exactly %d characters, got %d",
+			SecretKeySize, len(config.MFASecretKey))
+	}
+	if config.MFASecretKey == config.TokenSymmetricKey {
+		return fmt.Errorf("MFA_SECRET_KEY must differ from TOKEN_SYMMETRIC_KEY")
+	}
+	if config.Environment == EnvProduction {
+		if _unsafeKeys[config.MFASecretKey] || !hasGoodEntropy(config.MFASecretKey) {
+			return fmt.Errorf("MFA_SECRET_KEY is not safe for production")
+		}
+	}
+	return nil
+}
+
 // validateDatabaseConfig valida a configuração do banco
 func validateDatabaseConfig(connStr, environment string) error {
 	if connStr == "" {
@@ -196,16 +551,36 @@ func validateServiceAddresses(config *Config) error {
 	return nil
 }
 
-// validateAllowedIPs valida a lista de IPs permitidos
-func validateAllowedIPs(ips []string, environment string) error {
-	if len(ips) == 0 {
-		return fmt.Errorf("ALLOWED_IPS must contain at least one IP address")
+// validateAllowedIPs valida as listas de IPs e redes do filtro de IPs e dos
+// proxies confiáveis
+func validateAllowedIPs(config *Config) error {
+	allowed, err := ParseNetworks(config.AllowedIPs)
+	if err != nil {
+		return fmt.Errorf("ALLOWED_IPS: %w", err)
+	}
+	if _, err := ParseNetworks(config.DeniedIPs); err != nil {
+		return fmt.Errorf("DENIED_IPS: %w", err)
+	}
+	if _, err := ParseNetworks(config.TrustedProxies); err != nil {
+		return fmt.Errorf("TRUSTED_PROXIES: %w", err)
 	}
 
-	// Em produção, avisar se localhost está na lista
-	if environment == EnvProduction {
-		for _, ip := range ips {
-			if ip == "127.0.0.1" || ip == "localhost" {
+	switch config.IPFilterMode {
+	case IPFilterAllowlist:
+		if len(allowed) == 0 {
+			return fmt.Errorf("ALLOWED_IPS must contain at least one IP address or network")
+		}
+	case IPFilterDenylist:
+		return nil
+	default:
+		return fmt.Errorf("IP_FILTER_MODE must be %q or %q, got %q",
+			IPFilterAllowlist, IPFilterDenylist, config.IPFilterMode)
+	}
+
+	// Em produção, não permitir localhost na lista
+	if config.Environment == EnvProduction {
+		for _, network := range allowed {
+			if network.Addr().IsLoopback() {
 				return fmt.Errorf("localhost IPs detected in production environment")
 			}
 		}
@@ -214,6 +589,63 @@ func validateAllowedIPs(ips []string, environment string) error {
 	return nil
 }
 
+// validateCORS valida a política CORS padrão do gateway
+func validateCORS(config *Config) error {
+	for _, origin := range config.CORSAllowedOrigins {
+		if origin != "*" && strings.Count(origin, "*") > 1 {
+			return fmt.Errorf("CORS_ALLOWED_ORIGINS: origin %q has more than one wildcard", origin)
+		}
+		if config.Environment == EnvProduction && origin == "*" && config.CORSAllowCredentials {
+			return fmt.Errorf("CORS_ALLOWED_ORIGINS must not be '*' with credentials in production")
+		}
+	}
+	if config.CORSMaxAge < 0 {
+		return fmt.Errorf("CORS_MAX_AGE must not be negative")
+	}
+	return nil
+}
+
+// splitList separa uma lista de valores separados por vírgula, ignorando
+// itens vazios.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// validateRetryBudget valida o orçamento global de retries do gateway
+func validateRetryBudget(config *Config) error {
+	if config.RetryBudgetRatio < 0 || config.RetryBudgetRatio > 1 {
+		return fmt.Errorf("RETRY_BUDGET_RATIO must be between 0 and 1, got %v", config.RetryBudgetRatio)
+	}
+	if config.RetryBudgetMinPerSecond < 0 {
+		return fmt.Errorf("RETRY_BUDGET_MIN_PER_SECOND must not be negative")
+	}
+	return nil
+}
+
+// validateRateLimits valida os limites padrão e o backend do rate limiter
+func validateRateLimits(config *Config) error {
+	if config.RateLimitPerSecond <= 0 || config.RateLimitPerIP <= 0 || config.RateLimitPerUser <= 0 {
+		return fmt.Errorf("RATE_LIMIT_PER_SECOND, RATE_LIMIT_PER_IP and RATE_LIMIT_PER_USER must be positive")
+	}
+	if config.RateLimitBurst < 1 {
+		return fmt.Errorf("RATE_LIMIT_BURST must be at least 1")
+	}
+
+	switch config.RateLimitBackend {
+	case RateLimitBackendMemory, RateLimitBackendPostgres:
+		return nil
+	default:
+		return fmt.Errorf("RATE_LIMIT_BACKEND must be %q or %q, got %q",
+			RateLimitBackendMemory, RateLimitBackendPostgres, config.RateLimitBackend)
+	}
+}
+
 // hasGoodEntropy verifica se a string tem entropia suficiente
 func hasGoodEntropy(s string) bool {
 	// Contar caracteres únicos
@@ -246,3 +678,9 @@ func (c *Config) IsProduction() bool {
 func (c *Config) IsTesting() bool {
 	return c.Environment == EnvTesting
 }
+
+// MFAEnabled retorna true se MFA_SECRET_KEY estiver definida, o que liga o
+// cadastro de TOTP no serviço de auth
+func (c *Config) MFAEnabled() bool {
+	return c.MFASecretKey != ""
+}
@@ -0,0 +1,68 @@
+package util
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// SecretKeySize é o tamanho da chave de EncryptSecret (AES-256).
+const SecretKeySize = 32
+
+var errSecretKeySize = fmt.Errorf("secret key must be exactly %d bytes", SecretKeySize)
+
+// EncryptSecret cifra plaintext com AES-256-GCM para guardá-lo no banco. O
+// resultado é base64 do nonce seguido do texto cifrado. associatedData não é
+// cifrado, mas precisa ser o mesmo em DecryptSecret: amarra o valor a uma
+// linha (o dono do segredo), para que não possa ser copiado para outra.
+func EncryptSecret(key []byte, plaintext, associatedData string) (string, error) {
+	aead, err := newSecretAEAD(key)
+	if err != nil {
+		return "", fmt.Errorf("EncryptSecret: %w", err)
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("EncryptSecret: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(associatedData))
+	return base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret desfaz EncryptSecret. Chave errada, associatedData diferente
+// ou valor adulterado resultam em erro.
+func DecryptSecret(key []byte, ciphertext, associatedData string) (string, error) {
+	aead, err := newSecretAEAD(key)
+	if err != nil {
+		return "", fmt.Errorf("DecryptSecret: %w", err)
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("DecryptSecret: %w", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("DecryptSecret: ciphertext too short")
+	}
+
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, []byte(associatedData))
+	if err != nil {
+		return "", fmt.Errorf("DecryptSecret: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+func newSecretAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != SecretKeySize {
+		return nil, errSecretKeySize
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
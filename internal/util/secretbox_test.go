@@ -0,0 +1,50 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptSecret(t *testing.T) {
+	key := []byte(randomString(SecretKeySize))
+	secret, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+
+	ciphertext, err := EncryptSecret(key, secret, "alice")
+	require.NoError(t, err)
+	require.NotContains(t, ciphertext, secret)
+
+	// Nonce aleatório: o mesmo segredo nunca gera o mesmo valor
+	again, err := EncryptSecret(key, secret, "alice")
+	require.NoError(t, err)
+	require.NotEqual(t, ciphertext, again)
+
+	plaintext, err := DecryptSecret(key, ciphertext, "alice")
+	require.NoError(t, err)
+	require.Equal(t, secret, plaintext)
+
+	// Valor copiado para a linha de outro usuário
+	_, err = DecryptSecret(key, ciphertext, "bob")
+	require.Error(t, err)
+
+	// Outra chave
+	_, err = DecryptSecret([]byte(randomString(SecretKeySize)), ciphertext, "alice")
+	require.Error(t, err)
+
+	// Valor adulterado ou truncado
+	tampered := []byte(ciphertext)
+	tampered[len(tampered)/2] ^= 1
+	_, err = DecryptSecret(key, string(tampered), "alice")
+	require.Error(t, err)
+	_, err = DecryptSecret(key, ciphertext[:8], "alice")
+	require.Error(t, err)
+
+	// Segredo em texto puro, de antes da cifragem
+	_, err = DecryptSecret(key, secret, "alice")
+	require.Error(t, err)
+
+	_, err = EncryptSecret([]byte(strings.Repeat("k", 16)), secret, "alice")
+	require.ErrorIs(t, err, errSecretKeySize)
+}
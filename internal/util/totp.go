@@ -0,0 +1,135 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Parâmetros TOTP (RFC 6238) usados por todos os apps autenticadores comuns:
+// HMAC-SHA1, 6 dígitos e passos de 30 segundos.
+const (
+	TOTPDigits = 6
+	TOTPPeriod = 30 * time.Second
+	// TOTPSkew é quantos passos antes e depois do atual são aceitos, para
+	// tolerar relógios fora de sincronia
+	TOTPSkew = 1
+
+	totpSecretSize = 20
+)
+
+var _base32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret gera um segredo aleatório de 160 bits em base32, o
+// formato que os apps autenticadores aceitam.
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, totpSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("GenerateTOTPSecret: %w", err)
+	}
+	return _base32.EncodeToString(secret), nil
+}
+
+// TOTPStep é o passo (contador) TOTP do instante t.
+func TOTPStep(t time.Time) int64 {
+	return t.Unix() / int64(TOTPPeriod/time.Second)
+}
+
+// TOTPCode calcula o código do passo step (HOTP da RFC 4226 sobre o passo).
+func TOTPCode(secret string, step int64) (string, error) {
+	key, err := _base32.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", fmt.Errorf("TOTPCode: %w", err)
+	}
+	return hotp(key, uint64(step), TOTPDigits), nil
+}
+
+// VerifyTOTP confere code com os passos em torno de now e retorna o passo
+// aceito. Quem chama deve recusar passos já usados, para que um código
+// interceptado não sirva duas vezes.
+func VerifyTOTP(secret, code string, now time.Time) (int64, bool) {
+	if len(code) != TOTPDigits {
+		return 0, false
+	}
+
+	current := TOTPStep(now)
+	for step := current - TOTPSkew; step <= current+TOTPSkew; step++ {
+		expected, err := TOTPCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// TOTPProvisioningURI monta o URI otpauth:// que o app autenticador importa.
+// É também o conteúdo do QR code exibido no cadastro.
+func TOTPProvisioningURI(issuer, account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(TOTPDigits))
+	query.Set("period", fmt.Sprint(int(TOTPPeriod/time.Second)))
+
+	uri := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + account,
+		RawQuery: query.Encode(),
+	}
+	return uri.String()
+}
+
+// GenerateRecoveryCode gera um código de recuperação de 50 bits no formato
+// xxxxx-xxxxx, para quando o usuário perde o app autenticador.
+func GenerateRecoveryCode() (string, error) {
+	raw := make([]byte, 7)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("GenerateRecoveryCode: %w", err)
+	}
+	code := strings.ToLower(_base32.EncodeToString(raw))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// HashRecoveryCode é o hash guardado no banco. Os códigos são aleatórios e
+// longos o bastante para dispensar bcrypt, o que permite buscá-los pelo hash.
+// Maiúsculas, espaços e hífens digitados pelo usuário são ignorados.
+func HashRecoveryCode(code string) string {
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+func hotp(key []byte, counter uint64, digits int) string {
+	mac := hmac.New(sha1.New, key)
+	_ = binary.Write(mac, binary.BigEndian, counter)
+	sum := mac.Sum(nil)
+
+	// Truncamento dinâmico (RFC 4226, seção 5.3)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for range digits {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
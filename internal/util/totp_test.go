@@ -0,0 +1,86 @@
+package util
+
+import (
+	"encoding/base32"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Segredo dos vetores de teste das RFCs 4226 e 6238
+var _rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestHOTP(t *testing.T) {
+	// RFC 4226, apêndice D
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for counter, code := range want {
+		got, err := TOTPCode(_rfcSecret, int64(counter))
+		require.NoError(t, err)
+		require.Equal(t, code, got, "counter %d", counter)
+	}
+}
+
+func TestTOTP(t *testing.T) {
+	// RFC 6238, apêndice B (SHA1), com os 6 últimos dígitos
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		got, err := TOTPCode(_rfcSecret, TOTPStep(time.Unix(tt.unix, 0)))
+		require.NoError(t, err)
+		require.Equal(t, tt.code, got, "time %d", tt.unix)
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+
+	now := time.Now()
+	current := TOTPStep(now)
+	for _, step := range []int64{current - 1, current, current + 1} {
+		code, err := TOTPCode(secret, step)
+		require.NoError(t, err)
+		got, ok := VerifyTOTP(secret, code, now)
+		require.True(t, ok)
+		require.Equal(t, step, got)
+	}
+
+	old, err := TOTPCode(secret, current-2)
+	require.NoError(t, err)
+	_, ok := VerifyTOTP(secret, old, now)
+	require.False(t, ok)
+
+	_, ok = VerifyTOTP(secret, "12345", now)
+	require.False(t, ok)
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri, err := url.Parse(TOTPProvisioningURI("SigaCore", "maria", "JBSWY3DPEHPK3PXP"))
+	require.NoError(t, err)
+	require.Equal(t, "otpauth", uri.Scheme)
+	require.Equal(t, "totp", uri.Host)
+	require.Equal(t, "/SigaCore:maria", uri.Path)
+	require.Equal(t, "JBSWY3DPEHPK3PXP", uri.Query().Get("secret"))
+	require.Equal(t, "SigaCore", uri.Query().Get("issuer"))
+}
+
+func TestRecoveryCode(t *testing.T) {
+	code, err := GenerateRecoveryCode()
+	require.NoError(t, err)
+	require.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, code)
+
+	hash := HashRecoveryCode(code)
+	require.Equal(t, hash, HashRecoveryCode(strings.ToUpper(strings.ReplaceAll(code, "-", " "))))
+	require.NotEqual(t, hash, HashRecoveryCode("aaaaa-aaaaa"))
+}
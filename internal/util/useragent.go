@@ -0,0 +1,98 @@
+package util
+
+import (
+	"strings"
+)
+
+// Tipos de dispositivo reconhecidos em ParseUserAgent
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceOther   = "other"
+)
+
+// UserAgent é o resumo de um cabeçalho User-Agent para exibir ao usuário. Os
+// campos ficam vazios quando não reconhecidos.
+type UserAgent struct {
+	Browser        string `json:"browser,omitempty"`
+	BrowserVersion string `json:"browser_version,omitempty"`
+	OS             string `json:"os,omitempty"`
+	Device         string `json:"device"`
+}
+
+// _browsers é verificada em ordem: vários navegadores também anunciam
+// "Chrome/" e "Safari/", então os mais específicos vêm primeiro
+var _browsers = []struct {
+	token string
+	name  string
+}{
+	{"Edg/", "Edge"},
+	{"EdgiOS/", "Edge"},
+	{"OPR/", "Opera"},
+	{"SamsungBrowser/", "Samsung Internet"},
+	{"Firefox/", "Firefox"},
+	{"FxiOS/", "Firefox"},
+	{"CriOS/", "Chrome"},
+	{"Chrome/", "Chrome"},
+	{"Version/", "Safari"},
+	{"curl/", "curl"},
+	{"PostmanRuntime/", "Postman"},
+}
+
+var _systems = []struct {
+	token string
+	name  string
+}{
+	{"Windows", "Windows"},
+	{"iPhone", "iOS"},
+	{"iPad", "iPadOS"},
+	{"Android", "Android"},
+	{"CrOS", "ChromeOS"},
+	{"Mac OS X", "macOS"},
+	{"Linux", "Linux"},
+}
+
+// ParseUserAgent identifica navegador (com a versão principal), sistema
+// operacional e tipo de dispositivo a partir do User-Agent. É uma
+// heurística para exibição, não serve para decisões de segurança.
+func ParseUserAgent(ua string) UserAgent {
+	var parsed UserAgent
+
+	for _, browser := range _browsers {
+		if i := strings.Index(ua, browser.token); i >= 0 {
+			parsed.Browser = browser.name
+			parsed.BrowserVersion = majorVersion(ua[i+len(browser.token):])
+			break
+		}
+	}
+
+	for _, system := range _systems {
+		if strings.Contains(ua, system.token) {
+			parsed.OS = system.name
+			break
+		}
+	}
+
+	switch {
+	case strings.Contains(ua, "iPad") || strings.Contains(ua, "Tablet") ||
+		(parsed.OS == "Android" && !strings.Contains(ua, "Mobile")):
+		parsed.Device = DeviceTablet
+	case strings.Contains(ua, "Mobile") || strings.Contains(ua, "iPhone"):
+		parsed.Device = DeviceMobile
+	case parsed.OS != "":
+		parsed.Device = DeviceDesktop
+	default:
+		parsed.Device = DeviceOther
+	}
+	return parsed
+}
+
+// majorVersion retorna os dígitos iniciais de uma versão como "126.0.6478".
+func majorVersion(version string) string {
+	end := strings.IndexFunc(version, func(r rune) bool { return r < '0' || r > '9' })
+	if end < 0 {
+		return version
+	}
+	return version[:end]
+}
@@ -0,0 +1,51 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUserAgent(t *testing.T) {
+	tests := []struct {
+		ua   string
+		want UserAgent
+	}{
+		{
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",
+			want: UserAgent{Browser: "Chrome", BrowserVersion: "126", OS: "Windows", Device: DeviceDesktop},
+		},
+		{
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36 Edg/126.0.2592.87",
+			want: UserAgent{Browser: "Edge", BrowserVersion: "126", OS: "Windows", Device: DeviceDesktop},
+		},
+		{
+			ua:   "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_5) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Safari/605.1.15",
+			want: UserAgent{Browser: "Safari", BrowserVersion: "17", OS: "macOS", Device: DeviceDesktop},
+		},
+		{
+			ua:   "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1",
+			want: UserAgent{Browser: "Safari", BrowserVersion: "17", OS: "iOS", Device: DeviceMobile},
+		},
+		{
+			ua:   "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.6478.71 Mobile Safari/537.36",
+			want: UserAgent{Browser: "Chrome", BrowserVersion: "126", OS: "Android", Device: DeviceMobile},
+		},
+		{
+			ua:   "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:127.0) Gecko/20100101 Firefox/127.0",
+			want: UserAgent{Browser: "Firefox", BrowserVersion: "127", OS: "Linux", Device: DeviceDesktop},
+		},
+		{
+			ua:   "curl/8.5.0",
+			want: UserAgent{Browser: "curl", BrowserVersion: "8", Device: DeviceOther},
+		},
+		{
+			ua:   "",
+			want: UserAgent{Device: DeviceOther},
+		},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.want, ParseUserAgent(tt.ua), tt.ua)
+	}
+}
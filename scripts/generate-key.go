@@ -1,11 +1,20 @@
 package main
 
 import (
+	"crypto/ed25519"
 	"crypto/rand"
+	"crypto/x509"
 	"encoding/hex"
+	"encoding/pem"
 	"fmt"
 	"log"
 	"os"
+	"time"
+)
+
+const (
+	_privateKeyFile = "token_private.pem"
+	_publicKeyFile  = "token_public.pem"
 )
 
 func main() {
@@ -17,6 +26,17 @@ func main() {
 	fmt.Println("🔐 Gerador de Chaves Seguras para SIGA Core Gateway")
 	fmt.Println("==================================================")
 
+	if len(os.Args) > 1 && os.Args[1] == "--keypair" {
+		dir := "."
+		if len(os.Args) > 2 {
+			dir = os.Args[2]
+		}
+		if err := generateKeyPair(dir); err != nil {
+			log.Fatalf("Erro ao gerar par de chaves: %v", err)
+		}
+		return
+	}
+
 	// Gerar chave de 32 bytes (256 bits) para tokens
 	tokenKey, err := generateSecureKey(32)
 	if err != nil {
@@ -35,17 +55,23 @@ func main() {
 	fmt.Printf("- Use um gerenciador de segredos em produção\n")
 	fmt.Printf("- Considere rotacionar a chave periodicamente\n")
 
-	// Opcional: gerar múltiplas chaves para diferentes ambientes
+	// Opcional: gerar chaves agendadas para o keyring (TOKEN_KEYRING_FILE)
 	if len(os.Args) > 1 && os.Args[1] == "--multiple" {
-		fmt.Printf("\n🔄 Chaves adicionais para rotação:\n")
+		fmt.Printf("\n🔄 Chaves adicionais para rotação (TOKEN_KEYRING_FILE):\n")
+		fmt.Printf("keys:\n")
+		start := time.Now().UTC().Truncate(24 * time.Hour)
 		for i := 1; i <= 3; i++ {
 			key, err := generateSecureKey(32)
 			if err != nil {
 				log.Printf("Erro ao gerar chave %d: %v", i, err)
 				continue
 			}
-			fmt.Printf("BACKUP_KEY_%d=%s\n", i, key)
+			activateAt := start.AddDate(0, 3*(i-1), 0)
+			fmt.Printf("  - id: %q\n", activateAt.Format("2006-01-02"))
+			fmt.Printf("    symmetric_key: %q\n", key)
+			fmt.Printf("    activate_at: %s\n", activateAt.Format(time.RFC3339))
 		}
+		fmt.Printf("\n💡 Mantenha as chaves anteriores com retire_at até os tokens emitidos com elas expirarem\n")
 	}
 }
 
@@ -60,12 +86,61 @@ func generateSecureKey(length int) (string, error) {
 	return hex.EncodeToString(bytes)[:length], nil
 }
 
+// generateKeyPair gera um par de chaves Ed25519 para TOKEN_TYPE=paseto_public e
+// grava os dois arquivos PEM em dir, sem sobrescrever arquivos existentes
+func generateKeyPair(dir string) error {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return fmt.Errorf("falha ao gerar chaves: %w", err)
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return err
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+
+	privatePath, publicPath := dir+"/"+_privateKeyFile, dir+"/"+_publicKeyFile
+	if err := writePEM(privatePath, "PRIVATE KEY", privateDER, 0o600); err != nil {
+		return err
+	}
+	if err := writePEM(publicPath, "PUBLIC KEY", publicDER, 0o644); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n✅ Par de chaves Ed25519 gerado:\n")
+	fmt.Printf("TOKEN_TYPE=paseto_public\n")
+	fmt.Printf("TOKEN_PRIVATE_KEY_FILE=%s\n", privatePath)
+	fmt.Printf("TOKEN_PUBLIC_KEY_FILE=%s\n", publicPath)
+	fmt.Printf("\n🔑 Chave pública (hex): %s\n", hex.EncodeToString(publicKey))
+
+	fmt.Printf("\n⚠️  IMPORTANTE:\n")
+	fmt.Printf("- A chave privada fica apenas no serviço de auth\n")
+	fmt.Printf("- Gateway e backends recebem só a chave pública\n")
+	fmt.Printf("- NÃO armazene a chave privada em repositórios Git\n")
+	return nil
+}
+
+func writePEM(path, blockType string, der []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return fmt.Errorf("falha ao criar %s: %w", path, err)
+	}
+	defer f.Close()
+
+	return pem.Encode(f, &pem.Block{Type: blockType, Bytes: der})
+}
+
 func printHelp() {
 	fmt.Println("Gerador de Chaves Seguras - SIGA Core Gateway")
 	fmt.Println()
 	fmt.Println("USAGE:")
 	fmt.Println("  go run scripts/generate-key.go           # Gera uma chave")
-	fmt.Println("  go run scripts/generate-key.go --multiple # Gera múltiplas chaves")
+	fmt.Println("  go run scripts/generate-key.go --multiple # Gera chaves agendadas para o keyring")
+	fmt.Println("  go run scripts/generate-key.go --keypair [dir] # Gera par Ed25519 (PEM) para paseto_public")
 	fmt.Println("  go run scripts/generate-key.go --help     # Mostra esta ajuda")
 	fmt.Println()
 	fmt.Println("EXEMPLOS:")